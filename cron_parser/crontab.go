@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// crontabDefaultEnv is the environment cron gives every job before any
+// assignments in the crontab are applied
+var crontabDefaultEnv = map[string]string{
+	"SHELL": "/bin/sh",
+	"PATH":  "/usr/bin:/bin",
+}
+
+// crontabEnvLine matches a NAME=value environment assignment
+var crontabEnvLine = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)[ \t]*=[ \t]*(.*)$`)
+
+// CrontabJob is a scheduled line of a crontab file
+type CrontabJob struct {
+	Line int
+	Task *CronTask
+	// Env is the environment in effect for the job, cron's defaults
+	// overridden by the assignments on the lines before it
+	Env map[string]string
+}
+
+// CrontabError describes a line of a crontab file which couldn't be parsed
+type CrontabError struct {
+	Line int
+	Err  error
+}
+
+func (e CrontabError) Error() string {
+	return fmt.Sprintf("line %v: %v", e.Line, e.Err)
+}
+
+// Crontab is the result of parsing a whole crontab file
+type Crontab struct {
+	Jobs        []CrontabJob
+	Diagnostics []CrontabError
+}
+
+// EnvToString lists environment assignments sorted by name, quoting values
+// which contain blanks
+func EnvToString(env map[string]string) string {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		value := env[name]
+		if value == "" || strings.ContainsAny(value, " \t") {
+			value = strconv.Quote(value)
+		}
+		parts = append(parts, name+"="+value)
+	}
+	return strings.Join(parts, " ")
+}
+
+func (j CrontabJob) String() string {
+	return fmt.Sprintf("%-14s %v\n%v\n%-14s %v", "line", j.Line, strings.TrimSuffix(j.Task.String(), "\n"), "environment", EnvToString(j.Env))
+}
+
+// getEnvValue strips the matching quotes around an environment value
+func getEnvValue(value string) (string, error) {
+	if value == "" || (value[0] != '"' && value[0] != '\'') {
+		return value, nil
+	}
+	quote := value[0]
+	if len(value) < 2 || value[len(value)-1] != quote {
+		return "", fmt.Errorf("unterminated %c quote in environment value %v", quote, value)
+	}
+	return value[1 : len(value)-1], nil
+}
+
+// parseEnvLine checks whether a line is an environment assignment and
+// returns the name and value being assigned. A time zone prefix followed by
+// a schedule is a job rather than an assignment
+func parseEnvLine(line string) (name string, value string, isEnv bool, err error) {
+	match := crontabEnvLine.FindStringSubmatch(line)
+	if match == nil {
+		return "", "", false, nil
+	}
+	name, value = match[1], match[2]
+	for _, prefix := range timezonePrefixes {
+		if name+"=" == prefix && strings.ContainsAny(value, " \t") && value[0] != '"' && value[0] != '\'' {
+			return "", "", false, nil
+		}
+	}
+	value, err = getEnvValue(value)
+	return name, value, true, err
+}
+
+// ParseCrontab reads a crontab file, skipping comments and blank lines,
+// tracking environment assignments and compiling every job line. Lines which
+// can't be parsed are collected as diagnostics, the returned error is only
+// set when the file can't be read
+func ParseCrontab(r io.Reader, opts ...CompileOption) (*Crontab, error) {
+	crontab := &Crontab{}
+	env := make(map[string]string, len(crontabDefaultEnv))
+	for name, value := range crontabDefaultEnv {
+		env[name] = value
+	}
+
+	// location is the time zone of the last valid CRON_TZ assignment
+	var location *time.Location
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		name, value, isEnv, err := parseEnvLine(line)
+		if err != nil {
+			crontab.Diagnostics = append(crontab.Diagnostics, CrontabError{lineNo, err})
+			continue
+		}
+		if isEnv {
+			// An invalid time zone is reported where it's assigned and
+			// left out, rather than failing every job after it
+			if name == "CRON_TZ" {
+				zone, err := loadCrontabTimezone(value)
+				if err != nil {
+					crontab.Diagnostics = append(crontab.Diagnostics, CrontabError{lineNo, err})
+					continue
+				}
+				location = zone
+			}
+			env[name] = value
+			continue
+		}
+
+		task, err := CronTaskCompile(line, opts...)
+		if err != nil {
+			crontab.Diagnostics = append(crontab.Diagnostics, CrontabError{lineNo, err})
+			continue
+		}
+
+		// A CRON_TZ assignment applies to the jobs after it which don't set
+		// their own time zone
+		if task.Location == nil {
+			task.Location = location
+		}
+
+		jobEnv := make(map[string]string, len(env))
+		for name, value := range env {
+			jobEnv[name] = value
+		}
+		crontab.Jobs = append(crontab.Jobs, CrontabJob{Line: lineNo, Task: task, Env: jobEnv})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read the crontab: %v", err)
+	}
+	return crontab, nil
+}
+
+// loadCrontabTimezone loads the time zone of a CRON_TZ assignment, an empty
+// value clears it
+func loadCrontabTimezone(zone string) (*time.Location, error) {
+	if zone == "" {
+		return nil, nil
+	}
+	location, err := time.LoadLocation(zone)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't load the time zone: %v", err)
+	}
+	return location, nil
+}
+
+// systemCrontabPaths lists the crontab files and directories which have a
+// user column
+var systemCrontabPaths = []string{"/etc/crontab", "/etc/cron.d"}
+
+// isSystemCrontab checks whether a path is a system crontab, which has a user
+// column between the time fields and the command
+func isSystemCrontab(path string) bool {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	for _, systemPath := range systemCrontabPaths {
+		if path == systemPath || filepath.Dir(path) == systemPath {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseCrontabFile reads a crontab file, /etc/crontab and the files in
+// /etc/cron.d are parsed as system crontabs
+func ParseCrontabFile(path string, opts ...CompileOption) (*Crontab, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if isSystemCrontab(path) {
+		opts = append(slices.Clone(opts), WithSystemCrontab())
+	}
+	return ParseCrontab(file, opts...)
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseEnvLine(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedName  string
+		expectedValue string
+		expectedIsEnv bool
+		expectedError error
+	}{
+		{"SHELL=/bin/bash", "SHELL", "/bin/bash", true, nil},
+		{"MAILTO = ops@example.com", "MAILTO", "ops@example.com", true, nil},
+		{"MAILTO=\"\"", "MAILTO", "", true, nil},
+		{"GREETING='hello world'", "GREETING", "hello world", true, nil},
+		{"PATH=", "PATH", "", true, nil},
+		{"CRON_TZ=Europe/London", "CRON_TZ", "Europe/London", true, nil},
+		{"CRON_TZ=Europe/London 0 9 * * * /bin/report", "", "", false, nil},
+		{"*/5 * * * * FOO=bar /bin/job", "", "", false, nil},
+		{"BAD=\"open", "", "", true, fmt.Errorf("unterminated \" quote in environment value \"open")},
+	}
+
+	for _, test := range tests {
+		name, value, isEnv, err := parseEnvLine(test.input)
+		if fmt.Sprint(err) != fmt.Sprint(test.expectedError) {
+			t.Errorf("test %v, expected error %v, got %v", test.input, test.expectedError, err)
+		}
+		if err != nil {
+			continue
+		}
+		if name != test.expectedName || value != test.expectedValue || isEnv != test.expectedIsEnv {
+			t.Errorf("test %v, expected %v=%v (%v), got %v=%v (%v)",
+				test.input, test.expectedName, test.expectedValue, test.expectedIsEnv, name, value, isEnv)
+		}
+	}
+}
+
+func TestIsSystemCrontab(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"/etc/crontab", true},
+		{"/etc/cron.d/backup", true},
+		{"/etc/cron.d/../crontab", true},
+		{"/etc/cron.daily/backup", false},
+		{"/var/spool/cron/crontabs/root", false},
+		{"crontab", false},
+	}
+
+	for _, test := range tests {
+		res := isSystemCrontab(test.input)
+		if res != test.expected {
+			t.Errorf("test %v, expected %v, got %v", test.input, test.expected, res)
+		}
+	}
+}
+
+func TestParseCrontab(t *testing.T) {
+	crontab := strings.Join([]string{
+		"# nightly jobs",
+		"SHELL=/bin/bash",
+		"",
+		"MAILTO=\"ops@example.com\"",
+		"0 2 * * * /usr/bin/backup",
+		"   # indented comment",
+		"61 * * * * /bin/bad",
+		"PATH=/usr/local/bin:/usr/bin",
+		"BAD='open",
+		"CRON_TZ=Europe/London",
+		"@daily /bin/rotate",
+		"TZ=UTC 30 * * * * /bin/sync",
+		"CRON_TZ=Mars/Olympus_Mons",
+		"0 6 * * * /bin/wake",
+	}, "\n")
+
+	result, err := ParseCrontab(strings.NewReader(crontab))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expectedLines := []int{5, 11, 12, 14}
+	lines := []int{}
+	for _, job := range result.Jobs {
+		lines = append(lines, job.Line)
+	}
+	if !reflect.DeepEqual(lines, expectedLines) {
+		t.Errorf("expected jobs on lines %v, got %v", expectedLines, lines)
+	}
+
+	expectedEnv := []map[string]string{
+		{"SHELL": "/bin/bash", "PATH": "/usr/bin:/bin", "MAILTO": "ops@example.com"},
+		{"SHELL": "/bin/bash", "PATH": "/usr/local/bin:/usr/bin", "MAILTO": "ops@example.com", "CRON_TZ": "Europe/London"},
+		{"SHELL": "/bin/bash", "PATH": "/usr/local/bin:/usr/bin", "MAILTO": "ops@example.com", "CRON_TZ": "Europe/London"},
+		{"SHELL": "/bin/bash", "PATH": "/usr/local/bin:/usr/bin", "MAILTO": "ops@example.com", "CRON_TZ": "Europe/London"},
+	}
+	for i, job := range result.Jobs {
+		if i < len(expectedEnv) && !reflect.DeepEqual(job.Env, expectedEnv[i]) {
+			t.Errorf("test line %v, expected environment %v, got %v", job.Line, expectedEnv[i], job.Env)
+		}
+	}
+
+	// The invalid time zone on line 13 leaves the previous one in place
+	expectedLocations := []string{"", "Europe/London", "UTC", "Europe/London"}
+	for i, job := range result.Jobs {
+		location := ""
+		if job.Task.Location != nil {
+			location = job.Task.Location.String()
+		}
+		if i < len(expectedLocations) && location != expectedLocations[i] {
+			t.Errorf("test line %v, expected time zone %q, got %q", job.Line, expectedLocations[i], location)
+		}
+	}
+
+	expectedDiagnostics := []int{7, 9, 13}
+	diagnostics := []int{}
+	for _, diagnostic := range result.Diagnostics {
+		diagnostics = append(diagnostics, diagnostic.Line)
+	}
+	if !reflect.DeepEqual(diagnostics, expectedDiagnostics) {
+		t.Errorf("expected diagnostics on lines %v, got %v", expectedDiagnostics, result.Diagnostics)
+	}
+}
+
+func TestParseSystemCrontab(t *testing.T) {
+	crontab := strings.Join([]string{
+		"SHELL=/bin/sh",
+		"17 *\t* * *\troot    cd / && run-parts --report /etc/cron.hourly",
+		"25 6 * * * /usr/bin/backup",
+		"@reboot backup /usr/bin/backup --resume",
+	}, "\n")
+
+	result, err := ParseCrontab(strings.NewReader(crontab), WithSystemCrontab())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expectedUsers := []string{"root", "backup"}
+	users := []string{}
+	for _, job := range result.Jobs {
+		users = append(users, job.Task.User)
+	}
+	if !reflect.DeepEqual(users, expectedUsers) {
+		t.Errorf("expected users %v, got %v", expectedUsers, users)
+	}
+	if len(result.Diagnostics) != 1 || result.Diagnostics[0].Line != 3 {
+		t.Errorf("expected a diagnostic on line 3, got %v", result.Diagnostics)
+	}
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type DayRuleKind byte
+
+const (
+	// DayRuleLastDayOfMonth is the last day of the month, or a number of days
+	// before it (L, L-3)
+	DayRuleLastDayOfMonth DayRuleKind = iota
+	// DayRuleNearestWeekday is the weekday closest to a day of the month
+	// without leaving the month (15W)
+	DayRuleNearestWeekday
+	// DayRuleLastWeekday is the last weekday of the month (LW)
+	DayRuleLastWeekday
+	// DayRuleNthDayOfWeek is the nth occurrence of a day of the week in the
+	// month (1#1 for the first Monday)
+	DayRuleNthDayOfWeek
+	// DayRuleLastDayOfWeek is the last occurrence of a day of the week in the
+	// month (5L for the last Friday)
+	DayRuleLastDayOfWeek
+)
+
+func (k DayRuleKind) String() string {
+	switch k {
+	case DayRuleLastDayOfMonth:
+		return "LastDayOfMonth"
+	case DayRuleNearestWeekday:
+		return "NearestWeekday"
+	case DayRuleLastWeekday:
+		return "LastWeekday"
+	case DayRuleNthDayOfWeek:
+		return "NthDayOfWeek"
+	case DayRuleLastDayOfWeek:
+		return "LastDayOfWeek"
+	default:
+		return "Unknown"
+	}
+}
+
+// DayRule is a day constraint which depends on the month it is resolved in
+type DayRule struct {
+	Kind DayRuleKind
+	// Day is the day of the month a nearest weekday rule is closest to
+	Day int
+	// Offset is the number of days before the last day of the month
+	Offset int
+	// Weekday is the day of the week of the nth and last day of week rules
+	Weekday int
+	// Nth is the occurrence of the day of the week within the month, from 1
+	Nth int
+	// Expr is the rule as it was written (7L, FRI#2), the days of the week
+	// above are normalised so Expr is what explanations show
+	Expr string
+}
+
+func (r DayRule) String() string {
+	if r.Expr != "" {
+		return r.Expr
+	}
+	switch r.Kind {
+	case DayRuleLastDayOfMonth:
+		if r.Offset == 0 {
+			return "L"
+		}
+		return fmt.Sprintf("L-%v", r.Offset)
+	case DayRuleNearestWeekday:
+		return fmt.Sprintf("%vW", r.Day)
+	case DayRuleLastWeekday:
+		return "LW"
+	case DayRuleNthDayOfWeek:
+		return fmt.Sprintf("%v#%v", r.Weekday, r.Nth)
+	case DayRuleLastDayOfWeek:
+		return fmt.Sprintf("%vL", r.Weekday)
+	default:
+		return "?"
+	}
+}
+
+// daysInMonth returns the number of days in a month, accounting for leap years
+func daysInMonth(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func weekday(year int, month time.Month, day int) time.Weekday {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Weekday()
+}
+
+// Resolve returns the day of the month the rule selects in the given month,
+// ok is false if the rule doesn't select any day in that month
+func (r DayRule) Resolve(year int, month time.Month) (day int, ok bool) {
+	lastDay := daysInMonth(year, month)
+
+	switch r.Kind {
+	case DayRuleLastDayOfMonth:
+		day = lastDay - r.Offset
+		return day, day >= 1
+
+	case DayRuleNearestWeekday:
+		if r.Day > lastDay {
+			return 0, false
+		}
+		day = r.Day
+		switch weekday(year, month, day) {
+		case time.Saturday:
+			// Move to Friday unless that leaves the month
+			if day == 1 {
+				return day + 2, true
+			}
+			return day - 1, true
+		case time.Sunday:
+			// Move to Monday unless that leaves the month
+			if day == lastDay {
+				return day - 2, true
+			}
+			return day + 1, true
+		}
+		return day, true
+
+	case DayRuleLastWeekday:
+		day = lastDay
+		switch weekday(year, month, day) {
+		case time.Saturday:
+			day--
+		case time.Sunday:
+			day -= 2
+		}
+		return day, true
+
+	case DayRuleNthDayOfWeek:
+		firstDay := weekday(year, month, 1)
+		day = 1 + (r.Weekday-int(firstDay)+7)%7 + 7*(r.Nth-1)
+		return day, day <= lastDay
+
+	case DayRuleLastDayOfWeek:
+		lastWeekday := weekday(year, month, lastDay)
+		return lastDay - (int(lastWeekday)-r.Weekday+7)%7, true
+	}
+
+	return 0, false
+}
+
+// getDayRule converts a day rule node into its semantic representation,
+// keeping the rule as it was written
+func getDayRule(ast AstNode, field cronField) (DayRule, error) {
+	rule, err := getDayRuleValues(ast, field)
+	if err != nil {
+		return DayRule{}, err
+	}
+	rule.Expr = ast.Value
+	return rule, nil
+}
+
+func getDayRuleValues(ast AstNode, field cronField) (DayRule, error) {
+	ruleField := FieldDayOfMonth
+	if ast.NodeType == AstNthDayOfWeek || ast.NodeType == AstLastDayOfWeek {
+		ruleField = FieldDayOfWeek
+	}
+	if field.kind != ruleField {
+		return DayRule{}, fmt.Errorf("%v is only supported in the %v field, got it in the %v field",
+			ast.Value, ruleField, field.kind)
+	}
+
+	switch ast.NodeType {
+	case AstLastDayOfMonth:
+		rule := DayRule{Kind: DayRuleLastDayOfMonth}
+		if len(ast.Children) == 1 {
+			offset, err := strconv.Atoi(ast.Children[0].Value)
+			if err != nil || offset > field.maxVal-field.minVal {
+				return DayRule{}, fmt.Errorf("last day offset needs to be between 0 and %v, got %v",
+					field.maxVal-field.minVal, ast.Value)
+			}
+			rule.Offset = offset
+		}
+		return rule, nil
+
+	case AstNearestWeekday:
+		if len(ast.Children) != 1 {
+			return DayRule{}, fmt.Errorf("invalid nearest weekday format: %v", ast)
+		}
+		day, err := strconv.Atoi(ast.Children[0].Value)
+		if err != nil || day < field.minVal || day > field.maxVal {
+			return DayRule{}, fmt.Errorf("nearest weekday needs to be between %v and %v, got %v",
+				field.minVal, field.maxVal, ast.Value)
+		}
+		return DayRule{Kind: DayRuleNearestWeekday, Day: day}, nil
+
+	case AstLastWeekday:
+		return DayRule{Kind: DayRuleLastWeekday}, nil
+
+	case AstNthDayOfWeek:
+		if len(ast.Children) != 2 {
+			return DayRule{}, fmt.Errorf("invalid nth day of week format: %v", ast)
+		}
+		weekday, err := getRuleWeekday(ast.Children[0], field)
+		if err != nil {
+			return DayRule{}, err
+		}
+		nth, err := strconv.Atoi(ast.Children[1].Value)
+		if err != nil || nth < 1 || nth > 5 {
+			return DayRule{}, fmt.Errorf("day of week occurrence needs to be between 1 and 5, got %v", ast.Value)
+		}
+		return DayRule{Kind: DayRuleNthDayOfWeek, Weekday: weekday, Nth: nth}, nil
+
+	case AstLastDayOfWeek:
+		if len(ast.Children) != 1 {
+			return DayRule{}, fmt.Errorf("invalid last day of week format: %v", ast)
+		}
+		weekday, err := getRuleWeekday(ast.Children[0], field)
+		if err != nil {
+			return DayRule{}, err
+		}
+		return DayRule{Kind: DayRuleLastDayOfWeek, Weekday: weekday}, nil
+
+	default:
+		return DayRule{}, fmt.Errorf("invalid day rule format: %v", ast)
+	}
+}
+
+// getRuleWeekday resolves the day of the week a day of week rule applies to
+func getRuleWeekday(node AstNode, field cronField) (int, error) {
+	weekday, err := getNodeValue(node, field)
+	if err != nil {
+		return 0, err
+	}
+	if weekday < field.minVal || weekday > field.maxVal {
+		return 0, fmt.Errorf("day of week needs to be between %v and %v, got %v", field.minVal, field.maxVal, weekday)
+	}
+	return field.normalise(weekday) - field.shift, nil
+}
+
+// DaysOfMonthIn resolves the days of month the task runs on in a given month,
+// including any special day rules
+func (t CronTask) DaysOfMonthIn(year int, month time.Month) []int {
+	lastDay := daysInMonth(year, month)
+	days := make(map[int]struct{}, len(t.DaysOfMonth))
+
+	for _, day := range t.DaysOfMonth {
+		if day <= lastDay {
+			days[day] = struct{}{}
+		}
+	}
+	for _, rule := range t.DaysOfMonthRules {
+		if day, ok := rule.Resolve(year, month); ok {
+			days[day] = struct{}{}
+		}
+	}
+
+	values := make([]int, 0, len(days))
+	for day := range days {
+		values = append(values, day)
+	}
+	sort.Ints(values)
+	return values
+}
+
+// DaysOfWeekIn resolves the days of month which fall on the task's days of
+// the week in a given month, including any special day rules
+func (t CronTask) DaysOfWeekIn(year int, month time.Month) []int {
+	lastDay := daysInMonth(year, month)
+	weekdays := make(map[int]struct{}, len(t.DaysOfWeek))
+	for _, weekday := range t.DaysOfWeek {
+		weekdays[weekday] = struct{}{}
+	}
+
+	days := make(map[int]struct{})
+	for day := 1; day <= lastDay; day++ {
+		if _, found := weekdays[int(weekday(year, month, day))]; found {
+			days[day] = struct{}{}
+		}
+	}
+	for _, rule := range t.DaysOfWeekRules {
+		if day, ok := rule.Resolve(year, month); ok {
+			days[day] = struct{}{}
+		}
+	}
+
+	values := make([]int, 0, len(days))
+	for day := range days {
+		values = append(values, day)
+	}
+	sort.Ints(values)
+	return values
+}
+
+func (t CronTask) matchesDayOfMonth(year int, month time.Month, day int) bool {
+	if IntSliceContains(t.DaysOfMonth, day) {
+		return true
+	}
+	for _, rule := range t.DaysOfMonthRules {
+		if ruleDay, ok := rule.Resolve(year, month); ok && ruleDay == day {
+			return true
+		}
+	}
+	return false
+}
+
+func (t CronTask) matchesDayOfWeek(year int, month time.Month, day int) bool {
+	if IntSliceContains(t.DaysOfWeek, int(weekday(year, month, day))) {
+		return true
+	}
+	for _, rule := range t.DaysOfWeekRules {
+		if ruleDay, ok := rule.Resolve(year, month); ok && ruleDay == day {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesDay applies the cron day matching rule: when either day field is
+// unrestricted both have to match, otherwise matching either one is enough
+// unless the task always matches both
+func (t CronTask) matchesDay(year int, month time.Month, day int) bool {
+	if day > daysInMonth(year, month) {
+		return false
+	}
+	dayOfMonth := t.matchesDayOfMonth(year, month, day)
+	dayOfWeek := t.matchesDayOfWeek(year, month, day)
+	if t.MatchBothDays || t.DaysOfMonthUnrestricted || t.DaysOfWeekUnrestricted {
+		return dayOfMonth && dayOfWeek
+	}
+	return dayOfMonth || dayOfWeek
+}
+
+// MatchesDate checks whether the task runs on the given date, the time of day
+// is ignored. As in vixie cron, when both the day of month and the day of week
+// fields are restricted the task runs on days matching either of them. The
+// date is taken in the task's time zone when it has one
+func (t CronTask) MatchesDate(date time.Time) bool {
+	if t.Location != nil {
+		date = date.In(t.Location)
+	}
+	year, month, day := date.Date()
+	if !IntSliceContains(t.Months, int(month)) {
+		return false
+	}
+	if t.Years != nil && !IntSliceContains(t.Years, year) {
+		return false
+	}
+	return t.matchesDay(year, month, day)
+}
+
+// DaysIn lists the days of a month the task runs on, combining the day of
+// month and day of week fields the same way MatchesDate does
+func (t CronTask) DaysIn(year int, month time.Month) []int {
+	days := []int{}
+	for day := 1; day <= daysInMonth(year, month); day++ {
+		if t.matchesDay(year, month, day) {
+			days = append(days, day)
+		}
+	}
+	return days
+}
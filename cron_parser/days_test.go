@@ -0,0 +1,225 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDayRuleResolve(t *testing.T) {
+	tests := []struct {
+		inputRule  DayRule
+		inputYear  int
+		inputMonth time.Month
+		expectedOk bool
+		expected   int
+	}{
+		{DayRule{Kind: DayRuleLastDayOfMonth}, 2024, time.February, true, 29},
+		{DayRule{Kind: DayRuleLastDayOfMonth}, 2023, time.February, true, 28},
+		{DayRule{Kind: DayRuleLastDayOfMonth, Offset: 3}, 2024, time.April, true, 27},
+		{DayRule{Kind: DayRuleLastDayOfMonth, Offset: 30}, 2024, time.February, false, 0},
+		// 15th of June 2024 is a Saturday
+		{DayRule{Kind: DayRuleNearestWeekday, Day: 15}, 2024, time.June, true, 14},
+		// 16th of June 2024 is a Sunday
+		{DayRule{Kind: DayRuleNearestWeekday, Day: 16}, 2024, time.June, true, 17},
+		// 1st of June 2024 is a Saturday, Friday would be in the previous month
+		{DayRule{Kind: DayRuleNearestWeekday, Day: 1}, 2024, time.June, true, 3},
+		// 30th of June 2024 is a Sunday, Monday would be in the next month
+		{DayRule{Kind: DayRuleNearestWeekday, Day: 30}, 2024, time.June, true, 28},
+		{DayRule{Kind: DayRuleNearestWeekday, Day: 31}, 2024, time.June, false, 0},
+		{DayRule{Kind: DayRuleNearestWeekday, Day: 12}, 2024, time.June, true, 12},
+		// 31st of August 2024 is a Saturday
+		{DayRule{Kind: DayRuleLastWeekday}, 2024, time.August, true, 30},
+		// 30th of June 2024 is a Sunday
+		{DayRule{Kind: DayRuleLastWeekday}, 2024, time.June, true, 28},
+		{DayRule{Kind: DayRuleLastWeekday}, 2024, time.July, true, 31},
+		// 1st of June 2024 is a Saturday
+		{DayRule{Kind: DayRuleNthDayOfWeek, Weekday: 1, Nth: 1}, 2024, time.June, true, 3},
+		{DayRule{Kind: DayRuleNthDayOfWeek, Weekday: 6, Nth: 1}, 2024, time.June, true, 1},
+		{DayRule{Kind: DayRuleNthDayOfWeek, Weekday: 6, Nth: 5}, 2024, time.June, true, 29},
+		{DayRule{Kind: DayRuleNthDayOfWeek, Weekday: 1, Nth: 5}, 2024, time.June, false, 0},
+		{DayRule{Kind: DayRuleLastDayOfWeek, Weekday: 5}, 2024, time.June, true, 28},
+		{DayRule{Kind: DayRuleLastDayOfWeek, Weekday: 0}, 2024, time.June, true, 30},
+		{DayRule{Kind: DayRuleLastDayOfWeek, Weekday: 4}, 2024, time.February, true, 29},
+	}
+
+	for i, test := range tests {
+		day, ok := test.inputRule.Resolve(test.inputYear, test.inputMonth)
+
+		if ok != test.expectedOk {
+			t.Errorf("test %v, expected ok to be %v, got %v", i, test.expectedOk, ok)
+		}
+		if ok && day != test.expected {
+			t.Errorf("test %v, expected day %v, got %v", i, test.expected, day)
+		}
+	}
+}
+
+func TestDaysOfMonthIn(t *testing.T) {
+	tests := []struct {
+		inputTask  CronTask
+		inputYear  int
+		inputMonth time.Month
+		expected   []int
+	}{
+		{ // days past the end of the month are skipped
+			CronTask{DaysOfMonth: []int{1, 30, 31}},
+			2023, time.February,
+			[]int{1},
+		},
+		{ // rules are merged with the day values
+			CronTask{
+				DaysOfMonth:      []int{1, 28},
+				DaysOfMonthRules: []DayRule{{Kind: DayRuleLastDayOfMonth}, {Kind: DayRuleLastWeekday}},
+			},
+			2024, time.June,
+			[]int{1, 28, 30},
+		},
+	}
+
+	for i, test := range tests {
+		res := test.inputTask.DaysOfMonthIn(test.inputYear, test.inputMonth)
+
+		if !reflect.DeepEqual(res, test.expected) {
+			t.Errorf("test %v, expected %v, got %v", i, test.expected, res)
+		}
+	}
+}
+
+func TestDaysOfWeekIn(t *testing.T) {
+	tests := []struct {
+		inputTask  CronTask
+		inputYear  int
+		inputMonth time.Month
+		expected   []int
+	}{
+		{ // Mondays of June 2024
+			CronTask{DaysOfWeek: []int{1}},
+			2024, time.June,
+			[]int{3, 10, 17, 24},
+		},
+		{ // the first Monday and the last Friday of June 2024
+			CronTask{
+				DaysOfWeek: []int{},
+				DaysOfWeekRules: []DayRule{
+					{Kind: DayRuleNthDayOfWeek, Weekday: 1, Nth: 1},
+					{Kind: DayRuleLastDayOfWeek, Weekday: 5},
+				},
+			},
+			2024, time.June,
+			[]int{3, 28},
+		},
+	}
+
+	for i, test := range tests {
+		res := test.inputTask.DaysOfWeekIn(test.inputYear, test.inputMonth)
+
+		if !reflect.DeepEqual(res, test.expected) {
+			t.Errorf("test %v, expected %v, got %v", i, test.expected, res)
+		}
+	}
+}
+
+func TestMatchesDate(t *testing.T) {
+	allMonths := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+	tests := []struct {
+		inputTask CronTask
+		inputDate time.Time
+		expected  bool
+	}{
+		{ // both day fields restricted, only the day of month matches
+			CronTask{DaysOfMonth: []int{13}, Months: allMonths, DaysOfWeek: []int{5}},
+			time.Date(2024, time.June, 13, 0, 0, 0, 0, time.UTC),
+			true,
+		},
+		{ // both day fields restricted, only the day of week matches
+			CronTask{DaysOfMonth: []int{13}, Months: allMonths, DaysOfWeek: []int{5}},
+			time.Date(2024, time.June, 14, 0, 0, 0, 0, time.UTC),
+			true,
+		},
+		{ // both day fields restricted, neither matches
+			CronTask{DaysOfMonth: []int{13}, Months: allMonths, DaysOfWeek: []int{5}},
+			time.Date(2024, time.June, 15, 0, 0, 0, 0, time.UTC),
+			false,
+		},
+		{ // unrestricted day of month, only the day of week matters
+			CronTask{
+				DaysOfMonth:             []int{1, 3, 5, 7, 9, 11, 13, 15, 17, 19, 21, 23, 25, 27, 29, 31},
+				DaysOfMonthUnrestricted: true,
+				Months:                  allMonths,
+				DaysOfWeek:              []int{5},
+			},
+			time.Date(2024, time.June, 13, 0, 0, 0, 0, time.UTC),
+			false,
+		},
+		{ // unrestricted day of week, only the day of month matters
+			CronTask{
+				DaysOfMonth:            []int{13},
+				Months:                 allMonths,
+				DaysOfWeek:             []int{0, 1, 2, 3, 4, 5, 6},
+				DaysOfWeekUnrestricted: true,
+			},
+			time.Date(2024, time.June, 14, 0, 0, 0, 0, time.UTC),
+			false,
+		},
+		{ // day rules take part in the either rule
+			CronTask{
+				DaysOfMonth:      []int{},
+				DaysOfMonthRules: []DayRule{{Kind: DayRuleLastDayOfMonth}},
+				Months:           allMonths,
+				DaysOfWeek:       []int{1},
+			},
+			time.Date(2024, time.June, 30, 0, 0, 0, 0, time.UTC),
+			true,
+		},
+		{ // the date is taken in the task's time zone
+			CronTask{DaysOfMonth: []int{14}, Months: allMonths, DaysOfWeek: []int{}, Location: time.FixedZone("UTC+2", 2*60*60)},
+			time.Date(2024, time.June, 13, 23, 0, 0, 0, time.UTC),
+			true,
+		},
+		{ // month doesn't match
+			CronTask{DaysOfMonth: []int{13}, Months: []int{7}, DaysOfWeek: []int{5}},
+			time.Date(2024, time.June, 13, 0, 0, 0, 0, time.UTC),
+			false,
+		},
+	}
+
+	for i, test := range tests {
+		res := test.inputTask.MatchesDate(test.inputDate)
+
+		if res != test.expected {
+			t.Errorf("test %v, expected %v, got %v", i, test.expected, res)
+		}
+	}
+}
+
+func TestDaysIn(t *testing.T) {
+	tests := []struct {
+		inputCronStr string
+		inputYear    int
+		inputMonth   time.Month
+		expected     []int
+	}{
+		// Either the 1st or the 15th, or any Friday
+		{"0 0 1,15 * FRI cmd", 2024, time.June, []int{1, 7, 14, 15, 21, 28}},
+		// Odd days which are also Fridays, the day of month starts with an asterisk
+		{"0 0 */2 * FRI cmd", 2024, time.June, []int{7, 21}},
+		// Only the 1st and the 15th
+		{"0 0 1,15 * * cmd", 2024, time.June, []int{1, 15}},
+		// Sundays only
+		{"@weekly cmd", 2024, time.June, []int{2, 9, 16, 23, 30}},
+	}
+
+	for i, test := range tests {
+		task, err := CronTaskCompile(test.inputCronStr)
+		if err != nil {
+			t.Errorf("test %v, expected no error, got %v", i, err)
+			continue
+		}
+		res := task.DaysIn(test.inputYear, test.inputMonth)
+
+		if !reflect.DeepEqual(res, test.expected) {
+			t.Errorf("test %v, expected %v, got %v", i, test.expected, res)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// FieldUse says whether a dialect has one of the optional time fields
+type FieldUse byte
+
+const (
+	// FieldUnsupported fields can't be used
+	FieldUnsupported FieldUse = iota
+	// FieldOptional fields are expected when enabled with WithSeconds or WithYear
+	FieldOptional
+	// FieldRequired fields are always expected
+	FieldRequired
+)
+
+// FieldRange is the lowest and the highest value a time field accepts
+type FieldRange struct {
+	Min int
+	Max int
+}
+
+// Dialect describes the cron syntax accepted by a platform
+type Dialect struct {
+	Name string
+	// Seconds and Year say whether the dialect has a leading seconds field and
+	// a trailing year field
+	Seconds FieldUse
+	Year    FieldUse
+	// Ranges overrides the values accepted by the time fields, fields missing
+	// from the map accept their usual range
+	Ranges map[FieldKind]FieldRange
+	// Specials lists the operators accepted on top of * , - and /, out of
+	// L, W, #, H and ?
+	Specials string
+	// Macros lists the predefined schedules accepted in place of the time fields
+	Macros []string
+	// TimezonePrefixes lists the assignments accepted in front of the schedule
+	// to set its time zone
+	TimezonePrefixes []string
+	// SundayIsOne numbers the days of the week from 1 for Sunday to 7 for
+	// Saturday, instead of from 0 (or 7) for Sunday to 6 for Saturday
+	SundayIsOne bool
+	// ExclusiveDays expects ? in exactly one of the day of month and the day
+	// of week fields, the other one picks the days
+	ExclusiveDays bool
+}
+
+var allMacros = []string{"@yearly", "@annually", "@monthly", "@weekly", "@daily", "@midnight", "@hourly", "@reboot"}
+
+var (
+	// DefaultDialect accepts every syntax extension the parser knows about
+	DefaultDialect = Dialect{
+		Name:             "default",
+		Seconds:          FieldOptional,
+		Year:             FieldOptional,
+		Specials:         "LW#H?",
+		Macros:           append(allMacros, everyMacro),
+		TimezonePrefixes: timezonePrefixes,
+	}
+	// VixieDialect is the cron of most BSDs and older Linux distributions
+	VixieDialect = Dialect{
+		Name:   "vixie",
+		Macros: allMacros,
+	}
+	// CronieDialect is the cron of Fedora, RHEL and Arch Linux, it adds time
+	// zones to vixie cron
+	CronieDialect = Dialect{
+		Name:             "cronie",
+		Macros:           allMacros,
+		TimezonePrefixes: []string{"CRON_TZ="},
+	}
+	// QuartzDialect is the Java scheduler's cron expressions, they start with a
+	// seconds field and number the days of the week from 1
+	QuartzDialect = Dialect{
+		Name:          "quartz",
+		Seconds:       FieldRequired,
+		Year:          FieldOptional,
+		Ranges:        map[FieldKind]FieldRange{FieldDayOfWeek: {1, 7}},
+		Specials:      "LW#?",
+		SundayIsOne:   true,
+		ExclusiveDays: true,
+	}
+	// KubernetesDialect is the schedule of a Kubernetes CronJob, which takes
+	// its time zone from a CRON_TZ= or TZ= prefix when spec.timeZone isn't set
+	KubernetesDialect = Dialect{
+		Name:             "kubernetes",
+		Ranges:           map[FieldKind]FieldRange{FieldDayOfWeek: {0, 6}},
+		Specials:         "?",
+		Macros:           []string{"@yearly", "@annually", "@monthly", "@weekly", "@daily", "@midnight", "@hourly", everyMacro},
+		TimezonePrefixes: timezonePrefixes,
+	}
+	// EventBridgeDialect is the cron() schedule expression of AWS EventBridge,
+	// see ParseEventBridge
+	EventBridgeDialect = Dialect{
+		Name:          "eventbridge",
+		Year:          FieldRequired,
+		Ranges:        map[FieldKind]FieldRange{FieldDayOfWeek: {1, 7}, FieldYear: {1970, 2199}},
+		Specials:      "LW#?",
+		SundayIsOne:   true,
+		ExclusiveDays: true,
+	}
+	// BusyboxDialect is the crond of BusyBox, as found on Alpine Linux
+	BusyboxDialect = Dialect{
+		Name:   "busybox",
+		Ranges: map[FieldKind]FieldRange{FieldDayOfWeek: {0, 6}},
+		Macros: allMacros,
+	}
+)
+
+// dialects lists the dialects which can be looked up by name
+var dialects = []Dialect{DefaultDialect, VixieDialect, CronieDialect, QuartzDialect, KubernetesDialect, EventBridgeDialect, BusyboxDialect}
+
+// DialectNames lists the names of the known dialects, sorted
+func DialectNames() []string {
+	names := make([]string, len(dialects))
+	for i, dialect := range dialects {
+		names[i] = dialect.Name
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupDialect finds a known dialect by its name
+func LookupDialect(name string) (Dialect, error) {
+	for _, dialect := range dialects {
+		if strings.EqualFold(dialect.Name, name) {
+			return dialect, nil
+		}
+	}
+	return Dialect{}, fmt.Errorf("unknown dialect %v, expected one of %v", name, strings.Join(DialectNames(), " "))
+}
+
+// specialTokens maps the tokens of the special operators to the characters
+// dialects list them by
+var specialTokens = map[TokenType]rune{
+	TokenLast:     'L',
+	TokenWeekday:  'W',
+	TokenHash:     '#',
+	TokenHashed:   'H',
+	TokenQuestion: '?',
+}
+
+// checkTokens reports the first token using syntax the dialect doesn't accept
+func (d Dialect) checkTokens(tokens []Token) error {
+	for _, token := range tokens {
+		if special, found := specialTokens[token.tokType]; found && !strings.ContainsRune(d.Specials, special) {
+			return fmt.Errorf("%v is not supported by the %v dialect", string(token.value), d.Name)
+		}
+		if token.tokType == TokenMacro && !slices.Contains(d.Macros, string(token.value)) {
+			if _, known := cronMacros[string(token.value)]; !known && string(token.value) != everyMacro {
+				continue
+			}
+			if len(d.Macros) == 0 {
+				return fmt.Errorf("predefined schedules are not supported by the %v dialect, got %v",
+					d.Name, string(token.value))
+			}
+			return fmt.Errorf("predefined schedule %v is not supported by the %v dialect, expected one of %v",
+				string(token.value), d.Name, strings.Join(d.Macros, " "))
+		}
+	}
+	return nil
+}
+
+// checkTimezonePrefix reports a time zone assignment the dialect doesn't accept
+func (d Dialect) checkTimezonePrefix(prefix string) error {
+	if slices.Contains(d.TimezonePrefixes, prefix) {
+		return nil
+	}
+	if len(d.TimezonePrefixes) == 0 {
+		return fmt.Errorf("time zone assignments are not supported by the %v dialect, got %v", d.Name, prefix)
+	}
+	return fmt.Errorf("time zone assignment %v is not supported by the %v dialect, expected %v",
+		prefix, d.Name, strings.Join(d.TimezonePrefixes, " or "))
+}
+
+// applyTo restricts a time field to the values accepted by the dialect
+func (d Dialect) applyTo(field cronField) cronField {
+	if fieldRange, found := d.Ranges[field.kind]; found {
+		field.minVal, field.maxVal = fieldRange.Min, fieldRange.Max
+	}
+	if field.kind == FieldDayOfWeek && d.SundayIsOne {
+		field.aliases = nil
+		field.shift = 1
+	}
+	return field
+}
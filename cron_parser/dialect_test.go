@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestLookupDialect(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedName  string
+		expectedError error
+	}{
+		{"vixie", "vixie", nil},
+		{"Quartz", "quartz", nil},
+		{"kubernetes", "kubernetes", nil},
+		{"systemd", "", fmt.Errorf("unknown dialect systemd, expected one of busybox cronie default eventbridge kubernetes quartz vixie")},
+	}
+
+	for _, test := range tests {
+		dialect, err := LookupDialect(test.input)
+		if fmt.Sprint(err) != fmt.Sprint(test.expectedError) {
+			t.Errorf("test %v, expected error %v, got %v", test.input, test.expectedError, err)
+		}
+		if err == nil && dialect.Name != test.expectedName {
+			t.Errorf("test %v, expected %v, got %v", test.input, test.expectedName, dialect.Name)
+		}
+	}
+}
+
+func TestDialects(t *testing.T) {
+	tests := []struct {
+		inputCronStr  string
+		inputDialect  Dialect
+		inputOpts     []CompileOption
+		expectedError error
+	}{
+		{"*/5 * * * 7 /bin/job", VixieDialect, nil, nil},
+		{"@reboot /bin/job", VixieDialect, nil, nil},
+		{"0 0 L * * /bin/job", VixieDialect, nil,
+			fmt.Errorf("failed to tokenize your cron string: L is not supported by the vixie dialect")},
+		{"H * * * * /bin/job", VixieDialect, nil,
+			fmt.Errorf("failed to tokenize your cron string: H is not supported by the vixie dialect")},
+		{"0 0 0 * * * /bin/job", VixieDialect, []CompileOption{WithSeconds()},
+			fmt.Errorf("failed to tokenize your cron string: the vixie dialect doesn't have a seconds field")},
+		{"CRON_TZ=UTC 0 9 * * * /bin/job", VixieDialect, nil,
+			fmt.Errorf("failed to tokenize your cron string: time zone assignments are not supported by the vixie dialect, got CRON_TZ=")},
+		{"CRON_TZ=UTC 0 9 * * * /bin/job", CronieDialect, nil, nil},
+		{"TZ=UTC 0 9 * * * /bin/job", CronieDialect, nil,
+			fmt.Errorf("failed to tokenize your cron string: time zone assignment TZ= is not supported by the cronie dialect, expected CRON_TZ=")},
+		{"0 0 12 ? * MON-FRI /bin/job", QuartzDialect, nil, nil},
+		{"0 0 12 L * ? 2027 /bin/job", QuartzDialect, []CompileOption{WithYear()}, nil},
+		{"0 12 ? * MON /bin/job", QuartzDialect, nil,
+			fmt.Errorf("could not parse cron task: couldn't parse time field 6: couldn't parse time expression")},
+		{"0 0 12 * * MON /bin/job", QuartzDialect, nil,
+			fmt.Errorf("failed to extract valid cron task from syntax: the quartz dialect needs ? in exactly one of the day of month and day of week fields")},
+		{"0 0 12 ? * 0 /bin/job", QuartzDialect, nil,
+			fmt.Errorf("failed to extract valid cron task from syntax: invalid day of week field: time value needs to be between 1 and 7, got 0")},
+		{"0 0 * * ? /bin/job", KubernetesDialect, nil, nil},
+		{"0 0 * * 7 /bin/job", KubernetesDialect, nil,
+			fmt.Errorf("failed to extract valid cron task from syntax: invalid day of week field: time value needs to be between 0 and 6, got 7")},
+		{"CRON_TZ=Europe/London 0 9 * * * /bin/job", KubernetesDialect, nil, nil},
+		{"TZ=UTC 0 9 * * * /bin/job", KubernetesDialect, nil, nil},
+		{"@reboot /bin/job", KubernetesDialect, nil,
+			fmt.Errorf("failed to tokenize your cron string: predefined schedule @reboot is not supported by the kubernetes dialect, " +
+				"expected one of @yearly @annually @monthly @weekly @daily @midnight @hourly @every")},
+		{"0 0 * * ? /bin/job", BusyboxDialect, nil,
+			fmt.Errorf("failed to tokenize your cron string: ? is not supported by the busybox dialect")},
+		{"? 0 * * * /bin/job", DefaultDialect, nil,
+			fmt.Errorf("failed to extract valid cron task from syntax: invalid minute field: ? is only supported in the day of month and day of week fields, got it in the minute field")},
+	}
+
+	for i, test := range tests {
+		opts := append([]CompileOption{WithDialect(test.inputDialect)}, test.inputOpts...)
+		_, err := CronTaskCompile(test.inputCronStr, opts...)
+		if fmt.Sprint(err) != fmt.Sprint(test.expectedError) {
+			t.Errorf("test %v, expected error \"%v\", got \"%v\"", i, test.expectedError, err)
+		}
+	}
+}
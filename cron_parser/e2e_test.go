@@ -4,13 +4,21 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 )
 
+func mustLoadLocation(name string) *time.Location {
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return location
+}
 
 func TestCronTaskCompile(t *testing.T) {
 	tests := []struct {
-		inputCronStr string
-		expectedTask CronTask
+		inputCronStr  string
+		expectedTask  CronTask
 		expectedError error
 	}{
 		{ // Check basic use
@@ -37,6 +45,19 @@ func TestCronTaskCompile(t *testing.T) {
 			},
 			nil,
 		},
+		{ // Check percent signs split off the command's standard input
+			"0 0 1 1 1 date +\\%Y | mail -s report root%Dear root,%%see above",
+			CronTask{
+				Minutes:     []int{0},
+				Hours:       []int{0},
+				DaysOfMonth: []int{1},
+				Months:      []int{1},
+				DaysOfWeek:  []int{1},
+				Command:     "date +%Y | mail -s report root",
+				Stdin:       "Dear root,\n\nsee above",
+			},
+			nil,
+		},
 		{ // Check utf-8
 			"1 1 1 1 1 /usr/bin/💀",
 			CronTask{
@@ -62,12 +83,12 @@ func TestCronTaskCompile(t *testing.T) {
 		{ // No time fields
 			"test",
 			CronTask{},
-			fmt.Errorf("failed to tokenize your cron string: invalid Tokens found in the cron string: [t e s t], need 5 time space-separated time fields followed by a command"),
+			fmt.Errorf("could not parse cron task: couldn't parse time field 1: expected a space after time expression - got EOF() instead after parsing a complete time expression \"test\" for this field"),
 		},
 		{ // starting with space
 			" 1 1 1 1 1",
 			CronTask{},
-			fmt.Errorf("could not parse cron task: couldn't parse time field 1: couldn't parse time expression"),
+			fmt.Errorf("could not parse cron task: couldn't parse time field 5: expected a space after time expression - got EOF() instead after parsing a complete time expression \"1\" for this field"),
 		},
 		{ // single time field
 			"*",
@@ -77,22 +98,301 @@ func TestCronTaskCompile(t *testing.T) {
 		{ // single time field with a space
 			"* ",
 			CronTask{},
-			fmt.Errorf("could not parse cron task: couldn't parse time field 2: couldn't parse time expression"),
+			fmt.Errorf("could not parse cron task: couldn't parse time field 1: expected a space after time expression - got EOF() instead after parsing a complete time expression \"*\" for this field"),
+		},
+		{ // fields aligned with tabs and multiple spaces
+			" \t1  1\t1 \t 1    1\t/usr/bin/find  -name\t\"a  b\" ",
+			CronTask{
+				Minutes:     []int{1},
+				Hours:       []int{1},
+				DaysOfMonth: []int{1},
+				Months:      []int{1},
+				DaysOfWeek:  []int{1},
+				Command:     "/usr/bin/find  -name\t\"a  b\"",
+			},
+			nil,
+		},
+		{ // predefined schedule followed by tabs
+			"@reboot\t\t/usr/bin/startup",
+			CronTask{
+				Event:   "reboot",
+				Command: "/usr/bin/startup",
+			},
+			nil,
 		},
 		{ // invalid steps value
-			"1/10 1 1 1 1 test",
+			"1/x 1 1 1 1 test",
+			CronTask{},
+			fmt.Errorf("could not parse cron task: couldn't parse time field 1: expected a space after time expression - got Slash(/) instead after parsing a complete time expression \"1\" for this field, it's possible you have provided an invalid value (Name(x)) for the step number or the value range (Number(1))?"),
+		},
+		{ // steps from a single value
+			"1/10 1 1 1 MON/2 test",
+			CronTask{
+				Minutes:     []int{1, 11, 21, 31, 41, 51},
+				Hours:       []int{1},
+				DaysOfMonth: []int{1},
+				Months:      []int{1},
+				DaysOfWeek:  []int{0, 1, 3, 5},
+				Command:     "test",
+			},
+			nil,
+		},
+		{ // steps start value out of range
+			"1 1 0/10 1 1 test",
+			CronTask{},
+			fmt.Errorf("failed to extract valid cron task from syntax: invalid day of month field: steps start value needs to be between 1 and 31, got 0"),
+		},
+		{ // zero steps value
+			"*/0 1 1 1 1 test",
+			CronTask{},
+			fmt.Errorf("failed to extract valid cron task from syntax: invalid minute field: steps value needs to be at least 1, got 0"),
+		},
+		{ // invalid characters in the time fields
+			"1 1 1 1 1& test",
+			CronTask{},
+			fmt.Errorf("failed to tokenize your cron string: invalid Tokens found in the cron string: [&], need 5 time space-separated time fields followed by a command"),
+		},
+		{ // month and weekday names
+			"0 12 * JAN,jul MON-FRI /usr/bin/find",
+			CronTask{
+				Minutes:                 []int{0},
+				Hours:                   []int{12},
+				DaysOfMonth:             []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31},
+				Months:                  []int{1, 7},
+				DaysOfWeek:              []int{1, 2, 3, 4, 5},
+				DaysOfMonthUnrestricted: true,
+				Command:                 "/usr/bin/find",
+			},
+			nil,
+		},
+		{ // name ranges with steps
+			"0 0 1 Jan-Dec/3 sun,Sat /usr/bin/find",
+			CronTask{
+				Minutes:     []int{0},
+				Hours:       []int{0},
+				DaysOfMonth: []int{1},
+				Months:      []int{1, 4, 7, 10},
+				DaysOfWeek:  []int{0, 6},
+				Command:     "/usr/bin/find",
+			},
+			nil,
+		},
+		{ // weekday name in the month field
+			"0 0 1 MON * test",
+			CronTask{},
+			fmt.Errorf("failed to extract valid cron task from syntax: invalid month field: MON is not a valid name for the month field, expected one of JAN FEB MAR APR MAY JUN JUL AUG SEP OCT NOV DEC"),
+		},
+		{ // names in a field without aliases
+			"JAN 0 1 * * test",
+			CronTask{},
+			fmt.Errorf("failed to extract valid cron task from syntax: invalid minute field: names are not supported in the minute field, got JAN"),
+		},
+		{ // predefined schedule
+			"@daily /usr/bin/backup",
+			CronTask{
+				Minutes:                 []int{0},
+				Hours:                   []int{0},
+				DaysOfMonth:             []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31},
+				Months:                  []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12},
+				DaysOfWeek:              []int{0, 1, 2, 3, 4, 5, 6},
+				DaysOfMonthUnrestricted: true,
+				DaysOfWeekUnrestricted:  true,
+				Command:                 "/usr/bin/backup",
+			},
+			nil,
+		},
+		{ // predefined schedule on a specific weekday
+			"@weekly /usr/bin/backup --full",
+			CronTask{
+				Minutes:                 []int{0},
+				Hours:                   []int{0},
+				DaysOfMonth:             []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31},
+				Months:                  []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12},
+				DaysOfWeek:              []int{0},
+				DaysOfMonthUnrestricted: true,
+				Command:                 "/usr/bin/backup --full",
+			},
+			nil,
+		},
+		{ // event triggered task
+			"@reboot /usr/bin/startup",
+			CronTask{
+				Event:   "reboot",
+				Command: "/usr/bin/startup",
+			},
+			nil,
+		},
+		{ // unknown predefined schedule
+			"@sometimes /usr/bin/startup",
+			CronTask{},
+			fmt.Errorf("could not parse cron task: couldn't parse predefined schedule: unknown predefined schedule @sometimes, expected one of @yearly @annually @monthly @weekly @daily @midnight @hourly @reboot @every"),
+		},
+		{ // predefined schedule without a command
+			"@hourly",
 			CronTask{},
-			fmt.Errorf("could not parse cron task: couldn't parse time field 1: expected a space after time expression - got Slash(/) instead after parsing a complete time expression \"1\" for this field, it's possible you have provided an invalid value (Number(10)) for the step number or the value range (Number(1))?"),
+			fmt.Errorf("could not parse cron task: couldn't parse predefined schedule: expected a space after @hourly - got EOF() instead"),
+		},
+		{ // last day of the month
+			"0 0 L * * /usr/bin/bill",
+			CronTask{
+				Minutes:                []int{0},
+				Hours:                  []int{0},
+				DaysOfMonth:            []int{},
+				DaysOfMonthRules:       []DayRule{{Kind: DayRuleLastDayOfMonth, Expr: "L"}},
+				Months:                 []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12},
+				DaysOfWeek:             []int{0, 1, 2, 3, 4, 5, 6},
+				DaysOfWeekUnrestricted: true,
+				Command:                "/usr/bin/bill",
+			},
+			nil,
+		},
+		{ // day rules mixed with values
+			"0 0 1,L-3,15W,lw * * /usr/bin/bill",
+			CronTask{
+				Minutes:     []int{0},
+				Hours:       []int{0},
+				DaysOfMonth: []int{1},
+				DaysOfMonthRules: []DayRule{
+					{Kind: DayRuleLastDayOfMonth, Offset: 3, Expr: "L-3"},
+					{Kind: DayRuleNearestWeekday, Day: 15, Expr: "15W"},
+					{Kind: DayRuleLastWeekday, Expr: "LW"},
+				},
+				Months:                 []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12},
+				DaysOfWeek:             []int{0, 1, 2, 3, 4, 5, 6},
+				DaysOfWeekUnrestricted: true,
+				Command:                "/usr/bin/bill",
+			},
+			nil,
+		},
+		{ // day rules outside of the day of month field
+			"0 0 * L * /usr/bin/bill",
+			CronTask{},
+			fmt.Errorf("failed to extract valid cron task from syntax: invalid month field: L is only supported in the day of month field, got it in the month field"),
+		},
+		{ // nearest weekday out of range
+			"0 0 32W * * /usr/bin/bill",
+			CronTask{},
+			fmt.Errorf("failed to extract valid cron task from syntax: invalid day of month field: nearest weekday needs to be between 1 and 31, got 32W"),
+		},
+		{ // last day offset out of range
+			"0 0 L-31 * * /usr/bin/bill",
+			CronTask{},
+			fmt.Errorf("failed to extract valid cron task from syntax: invalid day of month field: last day offset needs to be between 0 and 30, got L-31"),
+		},
+		{ // nth and last day of the week
+			"0 9 * * 1#1,MON#3,5L /usr/bin/report",
+			CronTask{
+				Minutes:     []int{0},
+				Hours:       []int{9},
+				DaysOfMonth: []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31},
+				Months:      []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12},
+				DaysOfWeek:  []int{},
+				DaysOfWeekRules: []DayRule{
+					{Kind: DayRuleNthDayOfWeek, Weekday: 1, Nth: 1, Expr: "1#1"},
+					{Kind: DayRuleNthDayOfWeek, Weekday: 1, Nth: 3, Expr: "MON#3"},
+					{Kind: DayRuleLastDayOfWeek, Weekday: 5, Expr: "5L"},
+				},
+				DaysOfMonthUnrestricted: true,
+				Command:                 "/usr/bin/report",
+			},
+			nil,
+		},
+		{ // day of week rules outside of the day of week field
+			"0 9 1#1 * * /usr/bin/report",
+			CronTask{},
+			fmt.Errorf("failed to extract valid cron task from syntax: invalid day of month field: 1#1 is only supported in the day of week field, got it in the day of month field"),
+		},
+		{ // day of week occurrence out of range
+			"0 9 * * 1#6 /usr/bin/report",
+			CronTask{},
+			fmt.Errorf("failed to extract valid cron task from syntax: invalid day of week field: day of week occurrence needs to be between 1 and 5, got 1#6"),
+		},
+		{ // day of week out of range
+			"0 9 * * 9L /usr/bin/report",
+			CronTask{},
+			fmt.Errorf("failed to extract valid cron task from syntax: invalid day of week field: day of week needs to be between 0 and 7, got 9"),
+		},
+		{ // Sunday written as 7
+			"0 0 * * 5-7 /usr/bin/weekend",
+			CronTask{
+				Minutes:                 []int{0},
+				Hours:                   []int{0},
+				DaysOfMonth:             []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31},
+				Months:                  []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12},
+				DaysOfWeek:              []int{0, 5, 6},
+				DaysOfWeekExpr:          "5-7",
+				DaysOfMonthUnrestricted: true,
+				Command:                 "/usr/bin/weekend",
+			},
+			nil,
+		},
+		{ // Sunday written as both 0 and 7, and in steps and rules
+			"0 0 * * 0,7,1-7/3,7L /usr/bin/weekend",
+			CronTask{
+				Minutes:                 []int{0},
+				Hours:                   []int{0},
+				DaysOfMonth:             []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31},
+				Months:                  []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12},
+				DaysOfWeek:              []int{0, 1, 4},
+				DaysOfWeekRules:         []DayRule{{Kind: DayRuleLastDayOfWeek, Weekday: 0, Expr: "7L"}},
+				DaysOfWeekExpr:          "0,7,1-7/3,7L",
+				DaysOfMonthUnrestricted: true,
+				Command:                 "/usr/bin/weekend",
+			},
+			nil,
+		},
+		{ // day of week out of range
+			"0 0 * * 8 /usr/bin/weekend",
+			CronTask{},
+			fmt.Errorf("failed to extract valid cron task from syntax: invalid day of week field: time value needs to be between 0 and 7, got 8"),
+		},
+		{ // time zone assignment
+			"CRON_TZ=Europe/London\t0 9 * * 1-5 /usr/bin/report",
+			CronTask{
+				Minutes:                 []int{0},
+				Hours:                   []int{9},
+				DaysOfMonth:             []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31},
+				Months:                  []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12},
+				DaysOfWeek:              []int{1, 2, 3, 4, 5},
+				DaysOfMonthUnrestricted: true,
+				Location:                mustLoadLocation("Europe/London"),
+				Command:                 "/usr/bin/report",
+			},
+			nil,
+		},
+		{ // short time zone assignment with a predefined schedule
+			"TZ=UTC @reboot /usr/bin/startup",
+			CronTask{
+				Event:    "reboot",
+				Location: mustLoadLocation("UTC"),
+				Command:  "/usr/bin/startup",
+			},
+			nil,
+		},
+		{ // unknown time zone
+			"CRON_TZ=Mars/Olympus 0 9 * * * /usr/bin/report",
+			CronTask{},
+			fmt.Errorf("failed to extract valid cron task from syntax: couldn't load the time zone: unknown time zone Mars/Olympus"),
+		},
+		{ // empty time zone
+			"TZ= 0 9 * * * /usr/bin/report",
+			CronTask{},
+			fmt.Errorf("failed to extract valid cron task from syntax: time zone assignment needs a time zone name, e.g. CRON_TZ=Europe/London"),
+		},
+		{ // time zone without a schedule
+			"CRON_TZ=Europe/London",
+			CronTask{},
+			fmt.Errorf("could not parse cron task: expected a space after the time zone Europe/London - got EOF() instead"),
 		},
 		{ // invalid range for a field
 			"1 40-50 1 1 1 test",
 			CronTask{},
-			fmt.Errorf("failed to extract valid cron task from syntax: time range needs to be between 0 and 23, got 40 and 50"),
+			fmt.Errorf("failed to extract valid cron task from syntax: invalid hour field: time range needs to be between 0 and 23, got 40 and 50"),
 		},
 		{ // invalid steps for a field
 			"1 40-50/5 1 1 1 test",
 			CronTask{},
-			fmt.Errorf("failed to extract valid cron task from syntax: steps time range needs to be between 0 and 23, got 40 and 50"),
+			fmt.Errorf("failed to extract valid cron task from syntax: invalid hour field: steps time range needs to be between 0 and 23, got 40 and 50"),
 		},
 	}
 
@@ -117,3 +417,407 @@ func TestCronTaskCompile(t *testing.T) {
 	}
 }
 
+func TestCronTaskCompileOptions(t *testing.T) {
+	tests := []struct {
+		inputCronStr  string
+		inputOpts     []CompileOption
+		expectedTask  CronTask
+		expectedError error
+	}{
+		{ // seconds field
+			"*/20 0 12 * * MON-FRI /usr/bin/find",
+			[]CompileOption{WithSeconds()},
+			CronTask{
+				Seconds:                 []int{0, 20, 40},
+				Minutes:                 []int{0},
+				Hours:                   []int{12},
+				DaysOfMonth:             []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31},
+				Months:                  []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12},
+				DaysOfWeek:              []int{1, 2, 3, 4, 5},
+				DaysOfMonthUnrestricted: true,
+				Command:                 "/usr/bin/find",
+			},
+			nil,
+		},
+		{ // predefined schedules run on the first second
+			"@hourly /usr/bin/find",
+			[]CompileOption{WithSeconds()},
+			CronTask{
+				Seconds:                 []int{0},
+				Minutes:                 []int{0},
+				Hours:                   []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23},
+				DaysOfMonth:             []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31},
+				Months:                  []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12},
+				DaysOfWeek:              []int{0, 1, 2, 3, 4, 5, 6},
+				DaysOfMonthUnrestricted: true,
+				DaysOfWeekUnrestricted:  true,
+				Command:                 "/usr/bin/find",
+			},
+			nil,
+		},
+		{ // seconds out of range
+			"60 0 12 * * * /usr/bin/find",
+			[]CompileOption{WithSeconds()},
+			CronTask{},
+			fmt.Errorf("failed to extract valid cron task from syntax: invalid second field: time value needs to be between 0 and 59, got 60"),
+		},
+		{ // seconds and year fields
+			"0 0 12 1 JAN * 2027-2035/4,2099 /usr/bin/find",
+			[]CompileOption{WithSeconds(), WithYear()},
+			CronTask{
+				Seconds:                []int{0},
+				Minutes:                []int{0},
+				Hours:                  []int{12},
+				DaysOfMonth:            []int{1},
+				Months:                 []int{1},
+				DaysOfWeek:             []int{0, 1, 2, 3, 4, 5, 6},
+				Years:                  []int{2027, 2031, 2035, 2099},
+				DaysOfWeekUnrestricted: true,
+				Command:                "/usr/bin/find",
+			},
+			nil,
+		},
+		{ // year out of range
+			"0 12 * * * 2100 /usr/bin/find",
+			[]CompileOption{WithYear()},
+			CronTask{},
+			fmt.Errorf("failed to extract valid cron task from syntax: invalid year field: time value needs to be between 1970 and 2099, got 2100"),
+		},
+		{ // year range out of range
+			"0 12 * * * 1960-1980 /usr/bin/find",
+			[]CompileOption{WithYear()},
+			CronTask{},
+			fmt.Errorf("failed to extract valid cron task from syntax: invalid year field: time range needs to be between 1970 and 2099, got 1960 and 1980"),
+		},
+		{ // wrap-around ranges
+			"0 22-2 * * FRI-MON /usr/bin/night-shift",
+			[]CompileOption{WithWrapAround()},
+			CronTask{
+				Minutes:                 []int{0},
+				Hours:                   []int{0, 1, 2, 22, 23},
+				DaysOfMonth:             []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31},
+				Months:                  []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12},
+				DaysOfWeek:              []int{0, 1, 5, 6},
+				DaysOfMonthUnrestricted: true,
+				Command:                 "/usr/bin/night-shift",
+			},
+			nil,
+		},
+		{ // wrap-around ranges with steps
+			"0 22-2/2 * NOV-FEB/2 7-1/2 /usr/bin/night-shift",
+			[]CompileOption{WithWrapAround()},
+			CronTask{
+				Minutes:                 []int{0},
+				Hours:                   []int{0, 2, 22},
+				DaysOfMonth:             []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31},
+				Months:                  []int{1, 11},
+				DaysOfWeek:              []int{0},
+				DaysOfWeekExpr:          "7-1/2",
+				DaysOfMonthUnrestricted: true,
+				Command:                 "/usr/bin/night-shift",
+			},
+			nil,
+		},
+		{ // wrap-around ranges need to be enabled
+			"0 22-2 * * * /usr/bin/night-shift",
+			nil,
+			CronTask{},
+			fmt.Errorf("failed to extract valid cron task from syntax: invalid hour field: time range needs to start from a lower to a higher value, got 22-2 (enable wrap-around ranges to run from 22 past the end of the field to 2)"),
+		},
+		{ // wrap-around ranges need to stay within the field
+			"0 22-25 * * * /usr/bin/night-shift",
+			[]CompileOption{WithWrapAround()},
+			CronTask{},
+			fmt.Errorf("failed to extract valid cron task from syntax: invalid hour field: time range needs to be between 0 and 23, got 22 and 25"),
+		},
+		{ // hashed values derived from the seed
+			"H H * * * /usr/bin/backup",
+			[]CompileOption{WithHashSeed("nightly-backup")},
+			CronTask{
+				Minutes:                 []int{57},
+				Hours:                   []int{7},
+				DaysOfMonth:             []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31},
+				Months:                  []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12},
+				DaysOfWeek:              []int{0, 1, 2, 3, 4, 5, 6},
+				DaysOfMonthUnrestricted: true,
+				DaysOfWeekUnrestricted:  true,
+				Command:                 "/usr/bin/backup",
+			},
+			nil,
+		},
+		{ // hashed steps and ranges
+			"H/15 H(9-17) H * H(1-5) /usr/bin/backup",
+			[]CompileOption{WithHashSeed("nightly-backup")},
+			CronTask{
+				Minutes:     []int{12, 27, 42, 57},
+				Hours:       []int{13},
+				DaysOfMonth: []int{13},
+				Months:      []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12},
+				DaysOfWeek:  []int{1},
+				Command:     "/usr/bin/backup",
+			},
+			nil,
+		},
+		{ // hashed range out of range
+			"H(50-70) * * * * /usr/bin/backup",
+			nil,
+			CronTask{},
+			fmt.Errorf("failed to extract valid cron task from syntax: invalid minute field: hash range needs to be between 0 and 59, got 50 and 70"),
+		},
+		{ // missing seconds field
+			"0 12 * * * /usr/bin/find",
+			[]CompileOption{WithSeconds()},
+			CronTask{},
+			fmt.Errorf("could not parse cron task: couldn't parse time field 6: couldn't parse time expression"),
+		},
+		{ // quartz numbers the days of the week from 1 for Sunday
+			"0 30 9 ? * SUN,2-3,7#1 /usr/bin/report",
+			[]CompileOption{WithDialect(QuartzDialect)},
+			CronTask{
+				Seconds:                 []int{0},
+				Minutes:                 []int{30},
+				Hours:                   []int{9},
+				DaysOfMonth:             []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31},
+				Months:                  []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12},
+				DaysOfWeek:              []int{0, 1, 2},
+				DaysOfWeekRules:         []DayRule{{Kind: DayRuleNthDayOfWeek, Weekday: 6, Nth: 1, Expr: "7#1"}},
+				DaysOfMonthUnrestricted: true,
+				Command:                 "/usr/bin/report",
+			},
+			nil,
+		},
+		{ // interval schedule
+			"@every 1h30m /usr/bin/find",
+			nil,
+			CronTask{Interval: 90 * time.Minute, Command: "/usr/bin/find"},
+			nil,
+		},
+		{ // interval schedule without a command
+			"@every 45s",
+			[]CompileOption{WithScheduleOnly(), WithDialect(KubernetesDialect)},
+			CronTask{Interval: 45 * time.Second},
+			nil,
+		},
+		{ // interval below the minimum
+			"@every 500ms /usr/bin/find",
+			nil,
+			CronTask{},
+			fmt.Errorf("failed to extract valid cron task from syntax: @every interval needs to be at least 1s, got 500ms"),
+		},
+		{ // interval with a fraction of a second
+			"@every 1.5s /usr/bin/find",
+			nil,
+			CronTask{},
+			fmt.Errorf("failed to extract valid cron task from syntax: @every interval needs to be a whole number of seconds, got 1.5s"),
+		},
+		{ // interval which isn't a duration
+			"@every 5x /usr/bin/find",
+			nil,
+			CronTask{},
+			fmt.Errorf("failed to extract valid cron task from syntax: invalid @every interval: time: unknown unit \"x\" in duration \"5x\""),
+		},
+		{ // interval missing
+			"@every",
+			nil,
+			CronTask{},
+			fmt.Errorf("could not parse cron task: couldn't parse predefined schedule: expected an interval after @every, e.g. @every 1h30m"),
+		},
+		{ // schedule without a command
+			"*/15 0 1,15 * 1-5",
+			[]CompileOption{WithScheduleOnly()},
+			CronTask{
+				Minutes:     []int{0, 15, 30, 45},
+				Hours:       []int{0},
+				DaysOfMonth: []int{1, 15},
+				Months:      []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12},
+				DaysOfWeek:  []int{1, 2, 3, 4, 5},
+			},
+			nil,
+		},
+		{ // predefined schedule without a command
+			"@hourly",
+			[]CompileOption{WithScheduleOnly(), WithDialect(KubernetesDialect)},
+			CronTask{
+				Minutes:                 []int{0},
+				Hours:                   []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23},
+				DaysOfMonth:             []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31},
+				Months:                  []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12},
+				DaysOfWeek:              []int{0, 1, 2, 3, 4, 5, 6},
+				DaysOfMonthUnrestricted: true,
+				DaysOfWeekUnrestricted:  true,
+			},
+			nil,
+		},
+		{ // schedule followed by a command
+			"0 * * * * /usr/bin/find",
+			[]CompileOption{WithScheduleOnly()},
+			CronTask{},
+			fmt.Errorf("could not parse cron task: couldn't parse time field 5: expected the end of the schedule after " +
+				"time expression - got Space( ) instead after parsing a complete time expression \"*\" for this field"),
+		},
+		{ // schedule with a missing field
+			"0 * * *",
+			[]CompileOption{WithScheduleOnly()},
+			CronTask{},
+			fmt.Errorf("could not parse cron task: couldn't parse time field 4: expected a space after " +
+				"time expression - got EOF() instead after parsing a complete time expression \"*\" for this field"),
+		},
+		{ // system crontab user column
+			"17 * * * 1 root cd / && run-parts --report /etc/cron.hourly",
+			[]CompileOption{WithSystemCrontab()},
+			CronTask{
+				Minutes:                 []int{17},
+				Hours:                   []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23},
+				DaysOfMonth:             []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31},
+				Months:                  []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12},
+				DaysOfWeek:              []int{1},
+				DaysOfMonthUnrestricted: true,
+				User:                    "root",
+				Command:                 "cd / && run-parts --report /etc/cron.hourly",
+			},
+			nil,
+		},
+		{ // system crontab user after a predefined schedule
+			"@reboot\twww-data  /usr/bin/startup",
+			[]CompileOption{WithSystemCrontab()},
+			CronTask{Event: "reboot", User: "www-data", Command: "/usr/bin/startup"},
+			nil,
+		},
+		{ // system crontab missing the user
+			"0 * * * * /usr/bin/find -name core",
+			[]CompileOption{WithSystemCrontab()},
+			CronTask{},
+			fmt.Errorf("failed to extract valid cron task from syntax: /usr/bin/find is not a valid user name, " +
+				"expected up to 32 letters, digits, '_', '-' or '.' starting with a letter or '_'"),
+		},
+		{ // system crontab missing the command
+			"0 * * * * root",
+			[]CompileOption{WithSystemCrontab()},
+			CronTask{},
+			fmt.Errorf("could not parse cron task: expected a command after the user root - got EOF() instead"),
+		},
+	}
+
+	for i, test := range tests {
+		task, err := CronTaskCompile(test.inputCronStr, test.inputOpts...)
+
+		// Should return the correct error value
+		if test.expectedError != nil && err == nil {
+			t.Errorf("test %v, expected error \"%v\", got nil", i, test.expectedError)
+		} else if test.expectedError == nil && err != nil {
+			t.Errorf("test %v, expected no error, got \"%v\"", i, err)
+		} else if test.expectedError != nil && err != nil && err.Error() != test.expectedError.Error() {
+			t.Errorf("test %v, expected error \"%v\", got \"%v\"", i, test.expectedError, err)
+		}
+		if err != nil {
+			continue
+		}
+
+		if !reflect.DeepEqual(*task, test.expectedTask) {
+			t.Errorf("test %v, expected %v, got %v", i, test.expectedTask, *task)
+		}
+	}
+}
+
+func TestScheduleCompile(t *testing.T) {
+	task, err := ScheduleCompile("CRON_TZ=UTC 30 9 * * MON", WithDialect(CronieDialect))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if task.Command != "" || task.Location != time.UTC || !reflect.DeepEqual(task.DaysOfWeek, []int{1}) {
+		t.Errorf("expected a schedule on Mondays in UTC without a command, got %v", task)
+	}
+
+	// The caller's options are left alone
+	opts := make([]CompileOption, 1, 2)
+	opts[0] = WithSeconds()
+	if _, err := ScheduleCompile("0 30 9 * * MON", opts...); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if opts[:2][1] != nil {
+		t.Errorf("expected the options' spare capacity to be left alone")
+	}
+
+	// H needs a seed without a command to derive its values from
+	_, err = ScheduleCompile("H 9 * * MON")
+	expectedError := fmt.Errorf("failed to extract valid cron task from syntax: invalid minute field: " +
+		"H needs a seed when there's no command, set one with WithHashSeed (--seed)")
+	if fmt.Sprint(err) != fmt.Sprint(expectedError) {
+		t.Errorf("expected error %v, got %v", expectedError, err)
+	}
+	if _, err := ScheduleCompile("H 9 * * MON", WithHashSeed("backup")); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestCronTaskCompileHashSeed(t *testing.T) {
+	// The seed defaults to the command
+	first, err := CronTaskCompile("H H * * * /usr/bin/backup")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	seeded, err := CronTaskCompile("H H * * * /usr/bin/other", WithHashSeed("/usr/bin/backup"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(first.Minutes, seeded.Minutes) || !reflect.DeepEqual(first.Hours, seeded.Hours) {
+		t.Errorf("expected the command to seed the hash, got %v:%v and %v:%v",
+			first.Hours, first.Minutes, seeded.Hours, seeded.Minutes)
+	}
+
+	// Hashed values stay within the field
+	for i := 0; i < 100; i++ {
+		task, err := CronTaskCompile(fmt.Sprintf("H H H(5-7) H H /usr/bin/job-%v", i))
+		if err != nil {
+			t.Fatalf("test %v, expected no error, got %v", i, err)
+		}
+		if task.Minutes[0] > 59 || task.Hours[0] > 23 || task.Months[0] > 12 || task.DaysOfWeek[0] > 6 {
+			t.Errorf("test %v, hashed values out of range: %v", i, task)
+		}
+		if task.DaysOfMonth[0] < 5 || task.DaysOfMonth[0] > 7 {
+			t.Errorf("test %v, expected day of month between 5 and 7, got %v", i, task.DaysOfMonth)
+		}
+	}
+
+	// A range over the whole week, with Sunday written as 7, picks any day
+	for _, cronStr := range []string{"H H * * H(0-7) /usr/bin/job-%v", "H H * * H(0-7)/2 /usr/bin/job-%v"} {
+		firstDays := map[int]struct{}{}
+		for i := 0; i < 50; i++ {
+			task, err := CronTaskCompile(fmt.Sprintf(cronStr, i))
+			if err != nil {
+				t.Fatalf("test %v, expected no error, got %v", cronStr, err)
+			}
+			if task.DaysOfWeek[len(task.DaysOfWeek)-1] > 6 {
+				t.Errorf("test %v, hashed values out of range: %v", cronStr, task.DaysOfWeek)
+			}
+			firstDays[task.DaysOfWeek[0]] = struct{}{}
+		}
+		if len(firstDays) < 2 {
+			t.Errorf("test %v, expected more than one day to be picked, got %v", cronStr, firstDays)
+		}
+	}
+}
+
+func TestRunMatch(t *testing.T) {
+	tests := []struct {
+		inputArgs []string
+		expected  int
+	}{
+		{[]string{"*/15 9-17 * * 1-5 /usr/bin/find", "2024-06-13T09:45:00Z"}, 0},
+		{[]string{"--schedule", "*/15 9-17 * * 1-5", "2024-06-13T09:45:00Z"}, 0},
+		{[]string{"*/15 9-17 * * 1-5 /usr/bin/find", "2024-06-13T09:46:00Z"}, 1},
+		// Invalid input doesn't match either
+		{[]string{"*/15 9-17 * * 8 /usr/bin/find", "2024-06-13T09:45:00Z"}, 1},
+		{[]string{"*/15 9-17 * * 1-5 /usr/bin/find", "yesterday"}, 1},
+		{[]string{"*/15 9-17 * * 1-5 /usr/bin/find"}, 1},
+		{[]string{"--dialect", "systemd", "*/15 9-17 * * 1-5 /usr/bin/find", "2024-06-13T09:45:00Z"}, 1},
+	}
+
+	for i, test := range tests {
+		res := runMatch(test.inputArgs)
+
+		if res != test.expected {
+			t.Errorf("test %v, expected exit code %v, got %v", i, test.expected, res)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// eventBridgeRateUnits maps the units of rate() expressions to their duration
+var eventBridgeRateUnits = map[string]time.Duration{
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+}
+
+// ParseEventBridge compiles an AWS EventBridge schedule expression, either
+// cron(fields) with the six fields of the EventBridge dialect or
+// rate(value unit) for a task repeating at a fixed interval
+func ParseEventBridge(expression string) (*CronTask, error) {
+	expression = strings.TrimSpace(expression)
+	switch {
+	case strings.HasPrefix(expression, "cron(") && strings.HasSuffix(expression, ")"):
+		return parseEventBridgeCron(expression[len("cron(") : len(expression)-1])
+	case strings.HasPrefix(expression, "rate(") && strings.HasSuffix(expression, ")"):
+		return parseEventBridgeRate(expression[len("rate(") : len(expression)-1])
+	default:
+		return nil, fmt.Errorf("expected a cron(...) or a rate(...) expression, got %v", expression)
+	}
+}
+
+func parseEventBridgeCron(fields string) (*CronTask, error) {
+	fieldCount := len(strings.Fields(fields))
+	if fieldCount != 6 {
+		return nil, fmt.Errorf("cron expression needs 6 fields (minutes hours day-of-month month day-of-week year), got %v",
+			fieldCount)
+	}
+	return ScheduleCompile(fields, WithDialect(EventBridgeDialect))
+}
+
+func parseEventBridgeRate(rate string) (*CronTask, error) {
+	parts := strings.Fields(rate)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("rate expression needs a value and a unit, e.g. rate(5 minutes), got rate(%v)", rate)
+	}
+
+	value, err := strconv.Atoi(parts[0])
+	if err != nil || value < 1 {
+		return nil, fmt.Errorf("rate value needs to be a positive whole number, got %v", parts[0])
+	}
+
+	// A value of 1 takes the singular unit, any other value the plural one
+	unit := parts[1]
+	if value != 1 {
+		unit = strings.TrimSuffix(unit, "s")
+		if unit == parts[1] {
+			return nil, fmt.Errorf("rate unit for %v needs to be minutes, hours or days, got %v", value, parts[1])
+		}
+	}
+	duration, found := eventBridgeRateUnits[unit]
+	if !found && value == 1 {
+		return nil, fmt.Errorf("rate unit for 1 needs to be minute, hour or day, got %v", parts[1])
+	}
+	if !found {
+		return nil, fmt.Errorf("rate unit for %v needs to be minutes, hours or days, got %v", value, parts[1])
+	}
+	return &CronTask{Interval: time.Duration(value) * duration}, nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseEventBridge(t *testing.T) {
+	allYears := []int{}
+	for year := 1970; year <= 2199; year++ {
+		allYears = append(allYears, year)
+	}
+
+	tests := []struct {
+		input         string
+		expectedTask  CronTask
+		expectedError error
+	}{
+		{
+			"cron(0 12 ? * MON-FRI *)",
+			CronTask{
+				Minutes:                 []int{0},
+				Hours:                   []int{12},
+				DaysOfMonth:             []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31},
+				Months:                  []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12},
+				DaysOfWeek:              []int{1, 2, 3, 4, 5},
+				DaysOfMonthUnrestricted: true,
+				Years:                   allYears,
+			},
+			nil,
+		},
+		{
+			"cron(0/30 8-9 1,15 * ? 2030)",
+			CronTask{
+				Minutes:                []int{0, 30},
+				Hours:                  []int{8, 9},
+				DaysOfMonth:            []int{1, 15},
+				Months:                 []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12},
+				DaysOfWeek:             []int{0, 1, 2, 3, 4, 5, 6},
+				DaysOfWeekUnrestricted: true,
+				Years:                  []int{2030},
+			},
+			nil,
+		},
+		{ // L on its own is the last day of the week, Saturday
+			"cron(0 12 ? * L *)",
+			CronTask{
+				Minutes:                 []int{0},
+				Hours:                   []int{12},
+				DaysOfMonth:             []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31},
+				Months:                  []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12},
+				DaysOfWeek:              []int{6},
+				DaysOfWeekExpr:          "L",
+				DaysOfMonthUnrestricted: true,
+				Years:                   allYears,
+			},
+			nil,
+		},
+		{"rate(1 minute)", CronTask{Interval: time.Minute}, nil},
+		{"rate(12 hours)", CronTask{Interval: 12 * time.Hour}, nil},
+		{"rate(7 days)", CronTask{Interval: 7 * 24 * time.Hour}, nil},
+		{"cron(0 12 * * MON-FRI *)", CronTask{},
+			fmt.Errorf("failed to extract valid cron task from syntax: the eventbridge dialect needs ? in exactly one of the day of month and day of week fields")},
+		{"cron(0 12 ? * ? *)", CronTask{},
+			fmt.Errorf("failed to extract valid cron task from syntax: the eventbridge dialect needs ? in exactly one of the day of month and day of week fields")},
+		{"cron(0 12 ? * 0 *)", CronTask{},
+			fmt.Errorf("failed to extract valid cron task from syntax: invalid day of week field: time value needs to be between 1 and 7, got 0")},
+		{"cron(0 12 1 * ? 2200)", CronTask{},
+			fmt.Errorf("failed to extract valid cron task from syntax: invalid year field: time value needs to be between 1970 and 2199, got 2200")},
+		{"cron(0 0 12 1 * ? *)", CronTask{},
+			fmt.Errorf("cron expression needs 6 fields (minutes hours day-of-month month day-of-week year), got 7")},
+		{"cron(0 12 ? * L-2 *)", CronTask{},
+			fmt.Errorf("failed to extract valid cron task from syntax: invalid day of week field: L-2 is only supported in the day of month field, got it in the day of week field")},
+		{"cron(H 12 1 * ? *)", CronTask{},
+			fmt.Errorf("failed to tokenize your cron string: H is not supported by the eventbridge dialect")},
+		{"rate(1 minutes)", CronTask{}, fmt.Errorf("rate unit for 1 needs to be minute, hour or day, got minutes")},
+		{"rate(5 minute)", CronTask{}, fmt.Errorf("rate unit for 5 needs to be minutes, hours or days, got minute")},
+		{"rate(2 weeks)", CronTask{}, fmt.Errorf("rate unit for 2 needs to be minutes, hours or days, got weeks")},
+		{"rate(-5 minutes)", CronTask{}, fmt.Errorf("rate value needs to be a positive whole number, got -5")},
+		{"rate(5)", CronTask{}, fmt.Errorf("rate expression needs a value and a unit, e.g. rate(5 minutes), got rate(5)")},
+		{"0 12 * * ? *", CronTask{}, fmt.Errorf("expected a cron(...) or a rate(...) expression, got 0 12 * * ? *")},
+	}
+
+	for _, test := range tests {
+		task, err := ParseEventBridge(test.input)
+		if fmt.Sprint(err) != fmt.Sprint(test.expectedError) {
+			t.Errorf("test %v, expected error \"%v\", got \"%v\"", test.input, test.expectedError, err)
+		}
+		if err != nil {
+			continue
+		}
+		if !reflect.DeepEqual(*task, test.expectedTask) {
+			t.Errorf("test %v, expected %v, got %v", test.input, test.expectedTask, *task)
+		}
+	}
+}
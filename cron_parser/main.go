@@ -2,19 +2,95 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"slices"
+	"strings"
+	"time"
 )
 
-func getCronArg() (string, error) {
-	if len(os.Args) != 2 {
+func getCronArg(args []string) (string, error) {
+	if len(args) != 1 {
 		return "", fmt.Errorf("invalid arguments")
 	}
-	return os.Args[1], nil
+	return args[0], nil
+}
+
+// addCompileFlags defines the flags which set compile options, the returned
+// function reads the options once the flags are parsed
+func addCompileFlags(flags *flag.FlagSet) func() ([]CompileOption, error) {
+	seconds := flags.Bool("seconds", false, "expect a leading seconds field")
+	year := flags.Bool("year", false, "expect a trailing year field")
+	wrap := flags.Bool("wrap", false, "allow ranges which wrap around past the end of a field")
+	seed := flags.String("seed", "", "text the H operator derives its values from")
+	system := flags.Bool("system", false, "expect a user column between the time fields and the command")
+	dialectName := flags.String("dialect", "", "only accept the syntax of the given platform")
+	schedule := flags.Bool("schedule", false, "expect the time fields without a command")
+
+	return func() (opts []CompileOption, err error) {
+		if *seconds {
+			opts = append(opts, WithSeconds())
+		}
+		if *year {
+			opts = append(opts, WithYear())
+		}
+		if *wrap {
+			opts = append(opts, WithWrapAround())
+		}
+		if *seed != "" {
+			opts = append(opts, WithHashSeed(*seed))
+		}
+		if *system {
+			opts = append(opts, WithSystemCrontab())
+		}
+		if *schedule {
+			opts = append(opts, WithScheduleOnly())
+		}
+		if *dialectName != "" {
+			dialect, err := LookupDialect(*dialectName)
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, WithDialect(dialect))
+		}
+		return opts, nil
+	}
+}
+
+// getTimeArg parses an RFC 3339 timestamp given to a flag
+func getTimeArg(name string, value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %v time %v, expected an RFC 3339 timestamp such as 2006-01-02T15:04:05Z", name, value)
+	}
+	return t, nil
+}
+
+// getCompileFlags parses the command line flags into compile options
+func getCompileFlags(args []string) (opts []CompileOption, rest []string, err error) {
+	flags := flag.NewFlagSet("cronParser", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	compileOpts := addCompileFlags(flags)
+
+	if err = flags.Parse(args); err != nil {
+		return
+	}
+	if opts, err = compileOpts(); err != nil {
+		return nil, nil, err
+	}
+	return opts, flags.Args(), nil
 }
 
 func printUsage() {
-	fmt.Println("Usage: cronParser \"<cron string>\"")
+	fmt.Println("Usage: cronParser [flags] \"<cron string>\"")
+	fmt.Println("       cronParser crontab [flags] <file>")
+	fmt.Println("       cronParser systemd \"<OnCalendar= calendar event>\"")
+	fmt.Println("       cronParser oncalendar [flags] \"<cron string>\"")
+	fmt.Println("       cronParser runs [flags] --to <time> \"<cron string>\"")
+	fmt.Println("       cronParser match [flags] \"<cron string>\" <time>")
+	fmt.Println("       cronParser eventbridge \"cron(<fields>)\" | \"rate(<value> <unit>)\"")
 	fmt.Println("Example:")
 	fmt.Printf("\tcronParser \"*/15 0 1,15 * 1-5 /usr/bin/find\"\n\n")
 	fmt.Println("\tOutput: ")
@@ -24,14 +100,215 @@ func printUsage() {
 	fmt.Println("\tmonth          1 2 3 4 5 6 7 8 9 10 11 12")
 	fmt.Println("\tday of week    1 2 3 4 5")
 	fmt.Printf("\tcommand        /usr/bin/find\n\n")
+	fmt.Println("Flags:")
+	fmt.Println("\t--seconds      expect a leading seconds field")
+	fmt.Println("\t--year         expect a trailing year field (1970-2099)")
+	fmt.Println("\t--wrap         allow ranges which wrap around past the end of a field (22-2)")
+	fmt.Println("\t--seed <text>  text the H operator derives its values from, defaults to the command")
+	fmt.Println("\t--system       expect a user column between the time fields and the command, the default")
+	fmt.Println("\t               for /etc/crontab and the files in /etc/cron.d")
+	fmt.Println("\t--schedule     expect the time fields without a command, e.g. \"*/15 0 1,15 * 1-5\"")
+	fmt.Println("\t--dialect <name>")
+	fmt.Printf("\t               only accept the syntax of one platform: %v\n", strings.Join(DialectNames(), ", "))
+	fmt.Println("Times given to runs and match are in RFC 3339 format (2006-01-02T15:04:05Z07:00)")
+	fmt.Println("Flags of runs:")
+	fmt.Println("\t--from <time>  first time to list runs from, defaults to now")
+	fmt.Println("\t--to <time>    time to list runs up to, excluded")
+	fmt.Println("\t--limit <n>    list at most n runs")
 }
 
-func CronTaskCompile(cronStr string) (*CronTask, error) {
+// runCrontab checks every line of a crontab file, printing the jobs found and
+// a diagnostic for each line which couldn't be parsed
+func runCrontab(args []string) int {
+	opts, args, err := getCompileFlags(args)
+	if err != nil {
+		fmt.Printf("Error: %v\n\n", err)
+		printUsage()
+		return 2
+	}
+	path, err := getCronArg(args)
+	if err != nil {
+		printUsage()
+		return 2
+	}
+
+	crontab, err := ParseCrontabFile(path, opts...)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	// Jobs and diagnostics are printed in the order of their lines
+	diagnostics := crontab.Diagnostics
+	for i, job := range crontab.Jobs {
+		if i > 0 {
+			fmt.Println()
+		}
+		for len(diagnostics) > 0 && diagnostics[0].Line < job.Line {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", diagnostics[0])
+			diagnostics = diagnostics[1:]
+		}
+		fmt.Println(job)
+	}
+	for _, diagnostic := range diagnostics {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", diagnostic)
+	}
+	if len(crontab.Diagnostics) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// runRuns lists the run times of a cron string within a time window, one RFC
+// 3339 timestamp per line
+func runRuns(args []string) int {
+	flags := flag.NewFlagSet("cronParser runs", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	compileOpts := addCompileFlags(flags)
+	from := flags.String("from", "", "first time to list runs from")
+	to := flags.String("to", "", "time to list runs up to")
+	limit := flags.Int("limit", 0, "list at most n runs")
+
+	err := flags.Parse(args)
+	if err == nil && *to == "" {
+		err = fmt.Errorf("--to is required")
+	}
+	if err == nil && *limit < 0 {
+		err = fmt.Errorf("--limit needs to be at least 0, got %v", *limit)
+	}
+	var opts []CompileOption
+	if err == nil {
+		opts, err = compileOpts()
+	}
+	start, end := time.Now(), time.Time{}
+	if err == nil && *from != "" {
+		start, err = getTimeArg("--from", *from)
+	}
+	if err == nil {
+		end, err = getTimeArg("--to", *to)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n\n", err)
+		printUsage()
+		return 2
+	}
+	cronStr, err := getCronArg(flags.Args())
+	if err != nil {
+		printUsage()
+		return 2
+	}
+
+	task, err := CronTaskCompile(cronStr, opts...)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	runs := task.Runs(start, end, WithLimit(*limit))
+	for runs.Next() {
+		fmt.Println(runs.Time().Format(time.RFC3339))
+	}
+	if err := runs.Err(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runMatch checks whether a cron string runs at a given time, the exit code is
+// 0 when it does and 1 otherwise, also when the arguments are invalid, so that
+// scripts can test it
+func runMatch(args []string) int {
+	opts, args, err := getCompileFlags(args)
+	if err != nil {
+		fmt.Printf("Error: %v\n\n", err)
+		printUsage()
+		return 1
+	}
+	if len(args) != 2 {
+		printUsage()
+		return 1
+	}
+	at, err := getTimeArg("match", args[1])
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+
+	task, err := CronTaskCompile(args[0], opts...)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	if !task.Matches(at) {
+		return 1
+	}
+	return 0
+}
+
+// runEventBridge checks an AWS EventBridge schedule expression
+func runEventBridge(args []string) int {
+	expression, err := getCronArg(args)
+	if err != nil {
+		printUsage()
+		return 2
+	}
+	task, err := ParseEventBridge(expression)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	fmt.Println(task)
+	return 0
+}
+
+// runSystemd checks a systemd calendar event expression
+func runSystemd(args []string) int {
+	expression, err := getCronArg(args)
+	if err != nil {
+		printUsage()
+		return 2
+	}
+	task, err := ParseOnCalendar(expression)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	fmt.Println(task)
+	return 0
+}
+
+// runOnCalendar converts a cron string into a systemd OnCalendar= setting
+func runOnCalendar(args []string) int {
+	opts, args, err := getCompileFlags(args)
+	if err != nil {
+		fmt.Printf("Error: %v\n\n", err)
+		printUsage()
+		return 2
+	}
+	cronStr, err := getCronArg(args)
+	if err != nil {
+		printUsage()
+		return 2
+	}
+	task, err := CronTaskCompile(cronStr, opts...)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	calendar, err := task.ToOnCalendar()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("OnCalendar=%v\n", calendar)
+	return 0
+}
+
+func CronTaskCompile(cronStr string, opts ...CompileOption) (*CronTask, error) {
 
 	_, debug := os.LookupEnv("DEBUG")
 
 	// Convert raw string into a list of tokens
-	tokens, err := Tokenize(cronStr)
+	tokens, err := Tokenize(cronStr, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to tokenize your cron string: %v", err)
 	}
@@ -40,7 +317,7 @@ func CronTaskCompile(cronStr string) (*CronTask, error) {
 	}
 
 	// Convert tokens into an abstract syntax tree
-	ast, err := Parse(tokens)
+	ast, err := Parse(tokens, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("could not parse cron task: %v", err)
 	}
@@ -53,21 +330,55 @@ func CronTaskCompile(cronStr string) (*CronTask, error) {
 	}
 
 	// Convert the abstract syntax tree into a semantic cron task object
-	task, err := GetCronTask(ast)
+	task, err := GetCronTask(ast, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract valid cron task from syntax: %v", err)
 	}
 	return task, nil
 }
 
+// ScheduleCompile compiles just the time fields of a cron string, as found in
+// configuration files which keep the schedule apart from the command. The
+// task's command is empty, so the H operator needs a seed set with
+// WithHashSeed
+func ScheduleCompile(schedule string, opts ...CompileOption) (*CronTask, error) {
+	return CronTaskCompile(schedule, append(slices.Clone(opts), WithScheduleOnly())...)
+}
+
 func main() {
-	cronStr, err := getCronArg()
+	if len(os.Args) > 1 && os.Args[1] == "crontab" {
+		os.Exit(runCrontab(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "systemd" {
+		os.Exit(runSystemd(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "oncalendar" {
+		os.Exit(runOnCalendar(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "runs" {
+		os.Exit(runRuns(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "match" {
+		os.Exit(runMatch(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "eventbridge" {
+		os.Exit(runEventBridge(os.Args[2:]))
+	}
+
+	opts, args, err := getCompileFlags(os.Args[1:])
+	if err != nil {
+		fmt.Printf("Error: %v\n\n", err)
+		printUsage()
+		os.Exit(2)
+	}
+
+	cronStr, err := getCronArg(args)
 	if err != nil {
 		printUsage()
 		return
 	}
 
-	cronTask, err := CronTaskCompile(cronStr)
+	cronTask, err := CronTaskCompile(cronStr, opts...)
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
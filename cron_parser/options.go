@@ -0,0 +1,129 @@
+package main
+
+import "fmt"
+
+// CompileOptions holds the syntax extensions enabled when compiling a cron string
+type CompileOptions struct {
+	// Seconds expects a seconds field in front of the minute field
+	Seconds bool
+	// Year expects a year field after the day of week field
+	Year bool
+	// WrapAround allows ranges which run past the end of a field (22-2)
+	WrapAround bool
+	// HashSeed is the text the H operator derives its values from, the task's
+	// command is used when it is empty
+	HashSeed string
+	// SystemCrontab expects a user column between the time fields and the
+	// command, as in /etc/crontab and /etc/cron.d
+	SystemCrontab bool
+	// Dialect is the platform whose syntax is accepted, DefaultDialect unless
+	// set with WithDialect
+	Dialect Dialect
+	// ScheduleOnly expects the time fields without a command
+	ScheduleOnly bool
+}
+
+// CompileOption enables a syntax extension, see the With* functions
+type CompileOption func(*CompileOptions)
+
+// WithSeconds expects 6 time fields, the first of which holds the seconds
+func WithSeconds() CompileOption {
+	return func(o *CompileOptions) {
+		o.Seconds = true
+	}
+}
+
+// WithYear expects a trailing year field between 1970 and 2099
+func WithYear() CompileOption {
+	return func(o *CompileOptions) {
+		o.Year = true
+	}
+}
+
+// WithWrapAround allows ranges which start from a higher value to wrap around
+// past the end of the field, e.g. 22-2 for the hours from 22:00 to 02:00
+func WithWrapAround() CompileOption {
+	return func(o *CompileOptions) {
+		o.WrapAround = true
+	}
+}
+
+// WithHashSeed sets the text the H operator derives its values from, tasks
+// with different seeds get different but stable values
+func WithHashSeed(seed string) CompileOption {
+	return func(o *CompileOptions) {
+		o.HashSeed = seed
+	}
+}
+
+// WithSystemCrontab expects the user to run the command as between the time
+// fields and the command, as in /etc/crontab and /etc/cron.d
+func WithSystemCrontab() CompileOption {
+	return func(o *CompileOptions) {
+		o.SystemCrontab = true
+	}
+}
+
+// WithDialect only accepts the syntax of the given platform, with its field
+// ranges, operators and predefined schedules
+func WithDialect(dialect Dialect) CompileOption {
+	return func(o *CompileOptions) {
+		o.Dialect = dialect
+	}
+}
+
+// WithScheduleOnly expects just the time fields, as in configuration files
+// which hold the schedule apart from the command
+func WithScheduleOnly() CompileOption {
+	return func(o *CompileOptions) {
+		o.ScheduleOnly = true
+	}
+}
+
+func getCompileOptions(opts []CompileOption) CompileOptions {
+	options := CompileOptions{Dialect: DefaultDialect}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// timeFields lists the time fields in the order they appear in the cron string
+func (o CompileOptions) timeFields() []cronField {
+	fields := []cronField{}
+	if o.Dialect.Seconds == FieldRequired || (o.Dialect.Seconds == FieldOptional && o.Seconds) {
+		fields = append(fields, secondField)
+	}
+	fields = append(fields, minuteField, hourField, dayOfMonthField, monthField, dayOfWeekField)
+	if o.Dialect.Year == FieldRequired || (o.Dialect.Year == FieldOptional && o.Year) {
+		fields = append(fields, yearField)
+	}
+	for i := range fields {
+		fields[i] = o.Dialect.applyTo(fields[i])
+		fields[i].wrapAround = o.WrapAround
+	}
+	return fields
+}
+
+// fieldSeparator is the token expected after a time field, the last field of
+// a schedule without a command ends the schedule
+func (o CompileOptions) fieldSeparator(lastField bool) TokenType {
+	if lastField && o.ScheduleOnly {
+		return TokenEOF
+	}
+	return TokenSpace
+}
+
+// validate checks the enabled fields exist in the dialect
+func (o CompileOptions) validate() error {
+	if o.Seconds && o.Dialect.Seconds == FieldUnsupported {
+		return fmt.Errorf("the %v dialect doesn't have a seconds field", o.Dialect.Name)
+	}
+	if o.Year && o.Dialect.Year == FieldUnsupported {
+		return fmt.Errorf("the %v dialect doesn't have a year field", o.Dialect.Name)
+	}
+	if o.ScheduleOnly && o.SystemCrontab {
+		return fmt.Errorf("a schedule without a command can't have a user column")
+	}
+	return nil
+}
@@ -16,8 +16,19 @@ const (
 	AstTimeSteps
 	AstTimeRange
 	AstTimeVal
+	AstTimeName
 	AstAsterisk
 	AstTimeExpr
+	AstMacro
+	AstLastDayOfMonth
+	AstNearestWeekday
+	AstLastWeekday
+	AstNthDayOfWeek
+	AstLastDayOfWeek
+	AstHashed
+	AstTimezone
+	AstUser
+	AstDuration
 )
 
 func (t AstNodeType) String() string {
@@ -38,10 +49,32 @@ func (t AstNodeType) String() string {
 		return "TimeRange"
 	case AstTimeVal:
 		return "TimeVal"
+	case AstTimeName:
+		return "TimeName"
 	case AstAsterisk:
 		return "Asterisk"
 	case AstTimeExpr:
 		return "TimeExpr"
+	case AstMacro:
+		return "Macro"
+	case AstLastDayOfMonth:
+		return "LastDayOfMonth"
+	case AstNearestWeekday:
+		return "NearestWeekday"
+	case AstLastWeekday:
+		return "LastWeekday"
+	case AstNthDayOfWeek:
+		return "NthDayOfWeek"
+	case AstLastDayOfWeek:
+		return "LastDayOfWeek"
+	case AstHashed:
+		return "Hashed"
+	case AstTimezone:
+		return "Timezone"
+	case AstUser:
+		return "User"
+	case AstDuration:
+		return "Duration"
 	default:
 		return "Unknown"
 	}
@@ -61,6 +94,23 @@ func (n AstNode) String() string {
 	return fmt.Sprintf("{\"%v|%v\":%v},", n.NodeType, n.Value, n.Children)
 }
 
+// cronMacros maps the predefined schedules to the values of the time fields
+// they stand for, fields missing from the map match every value. Schedules
+// without any time fields are triggered by an event instead
+var cronMacros = map[string]map[FieldKind]string{
+	"@yearly":   {FieldSecond: "0", FieldMinute: "0", FieldHour: "0", FieldDayOfMonth: "1", FieldMonth: "1"},
+	"@annually": {FieldSecond: "0", FieldMinute: "0", FieldHour: "0", FieldDayOfMonth: "1", FieldMonth: "1"},
+	"@monthly":  {FieldSecond: "0", FieldMinute: "0", FieldHour: "0", FieldDayOfMonth: "1"},
+	"@weekly":   {FieldSecond: "0", FieldMinute: "0", FieldHour: "0", FieldDayOfWeek: "0"},
+	"@daily":    {FieldSecond: "0", FieldMinute: "0", FieldHour: "0"},
+	"@midnight": {FieldSecond: "0", FieldMinute: "0", FieldHour: "0"},
+	"@hourly":   {FieldSecond: "0", FieldMinute: "0"},
+	"@reboot":   nil,
+}
+
+// everyMacro repeats a task at a fixed interval, e.g. @every 1h30m
+const everyMacro = "@every"
+
 func lookahead(tokens []Token, tokensPtr int, expected []TokenType) bool {
 	if len(tokens) < tokensPtr+len(expected) {
 		return false
@@ -73,6 +123,18 @@ func lookahead(tokens []Token, tokensPtr int, expected []TokenType) bool {
 	return true
 }
 
+// parseValue converts a number or a name token into a single value node
+func parseValue(token Token) (node AstNode, success bool) {
+	switch token.tokType {
+	case TokenNumber:
+		return AstNode{AstTimeVal, string(token.value), []AstNode{}}, true
+	case TokenName:
+		return AstNode{AstTimeName, string(token.value), []AstNode{}}, true
+	default:
+		return AstNode{}, false
+	}
+}
+
 func parseTimeVal(tokens []Token, tokensPtr int) (node AstNode, newTokenPtr int, success bool) {
 
 	switch tokens[tokensPtr].tokType {
@@ -83,8 +145,8 @@ func parseTimeVal(tokens []Token, tokensPtr int) (node AstNode, newTokenPtr int,
 		success = true
 		return
 
-	case TokenNumber:
-		node = AstNode{AstTimeVal, string(tokens[tokensPtr].value), []AstNode{}}
+	case TokenNumber, TokenName:
+		node, _ = parseValue(tokens[tokensPtr])
 		newTokenPtr = tokensPtr + 1
 		success = true
 		return
@@ -100,21 +162,31 @@ func parseTimeRange(tokens []Token, tokensPtr int) (node AstNode, newTokenPtr in
 	tkptr := tokensPtr
 
 	// look ahead to see if the range production matches
-	if !lookahead(tokens, tkptr, []TokenType{TokenNumber, TokenDash, TokenNumber}) {
+	if len(tokens) < tkptr+3 || tokens[tkptr+1].tokType != TokenDash {
 		success = false
 		newTokenPtr = tokensPtr
 		return
 	}
 
 	// Parse the start value
-	rangeFrom := AstNode{AstTimeVal, string(tokens[tkptr].value), []AstNode{}}
+	rangeFrom, gotFrom := parseValue(tokens[tkptr])
+	if !gotFrom {
+		success = false
+		newTokenPtr = tokensPtr
+		return
+	}
 	tkptr++
 
 	// Skip the dash
 	tkptr++
 
 	// Parse the end value
-	rangeTo := AstNode{AstTimeVal, string(tokens[tkptr].value), []AstNode{}}
+	rangeTo, gotTo := parseValue(tokens[tkptr])
+	if !gotTo {
+		success = false
+		newTokenPtr = tokensPtr
+		return
+	}
 	tkptr++
 
 	rangeValue := fmt.Sprintf("%v-%v", rangeFrom.Value, rangeTo.Value)
@@ -125,19 +197,21 @@ func parseTimeRange(tokens []Token, tokensPtr int) (node AstNode, newTokenPtr in
 func parseTimeSteps(tokens []Token, tokensPtr int) (node AstNode, newTokenPtr int, success bool) {
 	tkptr := tokensPtr
 
-	// Parse either an Asterisk or a number range
+	// Parse either an Asterisk, a range or a single starting value
 	var stepsRange AstNode
+	var gotRange bool
 	if tokens[tkptr].tokType == TokenAsterisk {
 		stepsRange = AstNode{AstAsterisk, "*", []AstNode{}}
 		tkptr++
-	} else {
-		var gotRange bool
-		stepsRange, tkptr, gotRange = parseTimeRange(tokens, tkptr)
-		if !gotRange {
+	} else if stepsRange, tkptr, gotRange = parseTimeRange(tokens, tkptr); !gotRange {
+		var gotValue bool
+		stepsRange, gotValue = parseValue(tokens[tkptr])
+		if !gotValue {
 			newTokenPtr = tokensPtr
 			success = false
 			return
 		}
+		tkptr++
 	}
 
 	// Expect the slash
@@ -163,13 +237,111 @@ func parseTimeSteps(tokens []Token, tokensPtr int) (node AstNode, newTokenPtr in
 	return timeSteps, tkptr, true
 }
 
+func parseDayRule(tokens []Token, tokensPtr int) (node AstNode, newTokenPtr int, success bool) {
+	tkptr := tokensPtr
+
+	switch {
+	// The last weekday of the month (LW)
+	case lookahead(tokens, tkptr, []TokenType{TokenLast, TokenWeekday}):
+		return AstNode{AstLastWeekday, "LW", []AstNode{}}, tkptr + 2, true
+
+	// A number of days before the last day of the month (L-3)
+	case lookahead(tokens, tkptr, []TokenType{TokenLast, TokenDash, TokenNumber}):
+		offset := AstNode{AstTimeVal, string(tokens[tkptr+2].value), []AstNode{}}
+		return AstNode{AstLastDayOfMonth, "L-" + offset.Value, []AstNode{offset}}, tkptr + 3, true
+
+	// The last day of the month (L)
+	case lookahead(tokens, tkptr, []TokenType{TokenLast}):
+		return AstNode{AstLastDayOfMonth, "L", []AstNode{}}, tkptr + 1, true
+
+	// The weekday nearest to a day of the month (15W)
+	case lookahead(tokens, tkptr, []TokenType{TokenNumber, TokenWeekday}):
+		day := AstNode{AstTimeVal, string(tokens[tkptr].value), []AstNode{}}
+		return AstNode{AstNearestWeekday, day.Value + "W", []AstNode{day}}, tkptr + 2, true
+
+	// The last occurrence of a day of the week in the month (5L)
+	case lookahead(tokens, tkptr, []TokenType{TokenNumber, TokenLast}):
+		weekday := AstNode{AstTimeVal, string(tokens[tkptr].value), []AstNode{}}
+		return AstNode{AstLastDayOfWeek, weekday.Value + "L", []AstNode{weekday}}, tkptr + 2, true
+	}
+
+	// The nth occurrence of a day of the week in the month (1#1 or MON#1)
+	if len(tokens) >= tkptr+3 && lookahead(tokens, tkptr+1, []TokenType{TokenHash, TokenNumber}) {
+		weekday, gotWeekday := parseValue(tokens[tkptr])
+		if gotWeekday {
+			nth := AstNode{AstTimeVal, string(tokens[tkptr+2].value), []AstNode{}}
+			nthValue := fmt.Sprintf("%v#%v", weekday.Value, nth.Value)
+			return AstNode{AstNthDayOfWeek, nthValue, []AstNode{weekday, nth}}, tkptr + 3, true
+		}
+	}
+
+	newTokenPtr = tokensPtr
+	success = false
+	return
+}
+
+// parseHashed parses the jenkins H operator: H, H/15, H(0-29) or H(0-29)/10.
+// The first child of the node is the range to pick from, or an asterisk for
+// the whole field, the second child is the optional steps value
+func parseHashed(tokens []Token, tokensPtr int) (node AstNode, newTokenPtr int, success bool) {
+	tkptr := tokensPtr
+
+	if tokens[tkptr].tokType != TokenHashed {
+		newTokenPtr = tokensPtr
+		success = false
+		return
+	}
+	tkptr++
+	hashed := AstNode{AstHashed, "H", []AstNode{{AstAsterisk, "*", []AstNode{}}}}
+
+	// Parse the optional range in parentheses
+	if tokens[tkptr].tokType == TokenOpenParen {
+		hashRange, rangePtr, gotRange := parseTimeRange(tokens, tkptr+1)
+		if !gotRange || tokens[rangePtr].tokType != TokenCloseParen {
+			newTokenPtr = tokensPtr
+			success = false
+			return
+		}
+		hashed.Children[0] = hashRange
+		hashed.Value += fmt.Sprintf("(%v)", hashRange.Value)
+		tkptr = rangePtr + 1
+	}
+
+	// Parse the optional steps value
+	if lookahead(tokens, tkptr, []TokenType{TokenSlash, TokenNumber}) {
+		stepVal := AstNode{AstTimeVal, string(tokens[tkptr+1].value), []AstNode{}}
+		hashed.Children = append(hashed.Children, stepVal)
+		hashed.Value += "/" + stepVal.Value
+		tkptr += 2
+	}
+
+	return hashed, tkptr, true
+}
+
 func parseTimePart(tokens []Token, tokensPtr int) (node AstNode, newTokenPtr int, success bool) {
 	tkptr := tokensPtr
 
 	var partNode AstNode
 	var parseSuccess bool
 
-	// Try parsing a field with steps first as it's the longest match
+	// Try parsing day rules first as they start with the same tokens as values
+	partNode, tkptr, parseSuccess = parseDayRule(tokens, tkptr)
+	if parseSuccess {
+		return partNode, tkptr, true
+	}
+
+	// Try parsing the question mark, which leaves a day field unrestricted
+	if tokens[tkptr].tokType == TokenQuestion {
+		return AstNode{AstAsterisk, "?", []AstNode{}}, tkptr + 1, true
+	}
+
+	// Try parsing the hash operator, it can't be confused with other parts
+	partNode, tkptr, parseSuccess = parseHashed(tokens, tkptr)
+	if parseSuccess {
+		return partNode, tkptr, true
+	}
+
+	// Try parsing a field with steps next as it's the longest match
 	partNode, tkptr, parseSuccess = parseTimeSteps(tokens, tkptr)
 	if parseSuccess {
 		return partNode, tkptr, true
@@ -228,7 +400,15 @@ func parseTimeExpr(tokens []Token, tokensPtr int) (node AstNode, newTokenPtr int
 	return timeExpr, tkptr, true
 }
 
-func parseTimeField(tokens []Token, tokensPtr int) (node AstNode, newTokenPtr int, err error) {
+// separatorNames describes the tokens which can end a time field in errors
+var separatorNames = map[TokenType]string{
+	TokenSpace: "a space",
+	TokenEOF:   "the end of the schedule",
+}
+
+// parseTimeField parses a time expression ended by the separator, a space or
+// the end of a schedule without a command
+func parseTimeField(tokens []Token, tokensPtr int, separator TokenType) (node AstNode, newTokenPtr int, err error) {
 	tkptr := tokensPtr
 
 	// Parse the time expression
@@ -241,14 +421,14 @@ func parseTimeField(tokens []Token, tokensPtr int) (node AstNode, newTokenPtr in
 		return
 	}
 
-	// Expect a space
-	if tokens[tkptr].tokType != TokenSpace {
+	// Expect the separator
+	if tokens[tkptr].tokType != separator {
 		newTokenPtr = tokensPtr
 
 		err = fmt.Errorf(
-			"expected a space after time expression - got %v instead after "+
+			"expected %v after time expression - got %v instead after "+
 				"parsing a complete time expression \"%v\" for this field",
-			tokens[tkptr].String(), timeExpr.Value)
+			separatorNames[separator], tokens[tkptr].String(), timeExpr.Value)
 
 		// provide additional error context for step syntax
 		if tokens[tkptr].tokType == TokenSlash && tkptr+1 < len(tokens) && tkptr > 0 {
@@ -259,31 +439,136 @@ func parseTimeField(tokens []Token, tokensPtr int) (node AstNode, newTokenPtr in
 		}
 		return
 	}
-	tkptr++
+	// The end of the schedule is left for the caller
+	if separator != TokenEOF {
+		tkptr++
+	}
 
 	return AstNode{AstNodeField, timeExpr.Value, []AstNode{timeExpr}}, tkptr, nil
 }
 
-func parseTask(tokens []Token, tokensPtr int) (node AstNode, newTokenPtr int, err error) {
+func parseMacro(tokens []Token, tokensPtr int, timeFields []cronField, separator TokenType) (node AstNode, newTokenPtr int, err error) {
 	tkptr := tokensPtr
+
+	macroName := string(tokens[tkptr].value)
+	fieldValues, found := cronMacros[macroName]
+	if !found && macroName != everyMacro {
+		newTokenPtr = tokensPtr
+		err = fmt.Errorf("unknown predefined schedule %v, expected one of "+
+			"@yearly @annually @monthly @weekly @daily @midnight @hourly @reboot @every", macroName)
+		return
+	}
+	tkptr++
+	macro := AstNode{AstMacro, macroName, []AstNode{}}
+
+	// Parse the interval of an @every schedule
+	if macroName == everyMacro {
+		if !lookahead(tokens, tkptr, []TokenType{TokenSpace, TokenDuration}) {
+			newTokenPtr = tokensPtr
+			err = fmt.Errorf("expected an interval after %v, e.g. %v 1h30m", macroName, macroName)
+			return
+		}
+		macro.Children = append(macro.Children, AstNode{AstDuration, string(tokens[tkptr+1].value), []AstNode{}})
+		tkptr += 2
+	}
+
+	// Expand the macro into the time fields it replaces
+	for i := 0; fieldValues != nil && i < len(timeFields); i++ {
+		fieldValue, found := fieldValues[timeFields[i].kind]
+		if !found {
+			fieldValue = "*"
+		}
+		timePart := AstNode{AstTimeVal, fieldValue, []AstNode{}}
+		if fieldValue == "*" {
+			timePart = AstNode{AstAsterisk, fieldValue, []AstNode{}}
+		}
+		timeExpr := AstNode{AstTimeExpr, fieldValue, []AstNode{timePart}}
+		macro.Children = append(macro.Children, AstNode{AstNodeField, fieldValue, []AstNode{timeExpr}})
+	}
+
+	// Expect the separator
+	if tokens[tkptr].tokType != separator {
+		newTokenPtr = tokensPtr
+		err = fmt.Errorf("expected %v after %v - got %v instead", separatorNames[separator], macroName, tokens[tkptr].String())
+		return
+	}
+	if separator != TokenEOF {
+		tkptr++
+	}
+
+	return macro, tkptr, nil
+}
+
+func parseTask(tokens []Token, tokensPtr int, options CompileOptions) (node AstNode, newTokenPtr int, err error) {
+	tkptr := tokensPtr
+	timeFields := options.timeFields()
 	task := AstNode{AstNodeTask, "", []AstNode{}}
 
-	// Parse 5 time fields
-	for i := 0; i < 5; i++ {
-		var field AstNode
-		field, tkptr, err = parseTimeField(tokens, tkptr)
+	// Parse the optional time zone assignment
+	if tokens[tkptr].tokType == TokenTimezone {
+		timezone := AstNode{AstTimezone, string(tokens[tkptr].value), []AstNode{}}
+		tkptr++
+		if tokens[tkptr].tokType != TokenSpace {
+			newTokenPtr = tokensPtr
+			err = fmt.Errorf("expected a space after the time zone %v - got %v instead",
+				timezone.Value, tokens[tkptr].String())
+			return
+		}
+		tkptr++
+		task.Children = append(task.Children, timezone)
+	}
+
+	// Parse a predefined schedule in place of the time fields
+	if tokens[tkptr].tokType == TokenMacro {
+		var macro AstNode
+		macro, tkptr, err = parseMacro(tokens, tkptr, timeFields, options.fieldSeparator(true))
 		if err != nil {
 			newTokenPtr = tokensPtr
-			err = fmt.Errorf("couldn't parse time field %v: %v", i+1, err)
+			err = fmt.Errorf("couldn't parse predefined schedule: %v", err)
 			return
 		}
-		task.Children = append(task.Children, field)
+		task.Children = append(task.Children, macro)
+	} else {
+		// Parse each of the time fields
+		for i := range timeFields {
+			var field AstNode
+			field, tkptr, err = parseTimeField(tokens, tkptr, options.fieldSeparator(i == len(timeFields)-1))
+			if err != nil {
+				newTokenPtr = tokensPtr
+				err = fmt.Errorf("couldn't parse time field %v: %v", i+1, err)
+				return
+			}
+			task.Children = append(task.Children, field)
+		}
+	}
+
+	// Parse the user column of a system crontab
+	if options.SystemCrontab {
+		if tokens[tkptr].tokType != TokenUser {
+			newTokenPtr = tokensPtr
+			err = fmt.Errorf("expected a user after the time fields - got %v instead", tokens[tkptr].String())
+			return
+		}
+		user := AstNode{AstUser, string(tokens[tkptr].value), []AstNode{}}
+		tkptr++
+		if tokens[tkptr].tokType != TokenSpace {
+			newTokenPtr = tokensPtr
+			err = fmt.Errorf("expected a command after the user %v - got %v instead", user.Value, tokens[tkptr].String())
+			return
+		}
+		tkptr++
+		task.Children = append(task.Children, user)
+	}
+
+	// A schedule on its own ends with the time fields
+	if options.ScheduleOnly {
+		return task, tkptr, nil
 	}
 
 	// Parse the command
 	if tokens[tkptr].tokType != TokenCommand {
 		newTokenPtr = tokensPtr
-		err = fmt.Errorf("expected 5 space-separated time fields followed by a command")
+		err = fmt.Errorf("expected %v space-separated time fields followed by a command", len(timeFields))
 		return
 	}
 	command := AstNode{AstNodeCommand, string(tokens[tkptr].value), []AstNode{}}
@@ -293,13 +578,17 @@ func parseTask(tokens []Token, tokensPtr int) (node AstNode, newTokenPtr int, er
 	return task, tkptr, nil
 }
 
-func Parse(tokens []Token) (*AstNode, error) {
-	root, tokenPtr, err := parseTask(tokens, 0)
+func Parse(tokens []Token, opts ...CompileOption) (*AstNode, error) {
+	options := getCompileOptions(opts)
+	root, tokenPtr, err := parseTask(tokens, 0, options)
 	if err != nil {
 		return nil, err
 	}
+	if tokenPtr+1 != len(tokens) && options.ScheduleOnly {
+		return nil, fmt.Errorf("incorrect format: expected %v space-separated time fields", len(options.timeFields()))
+	}
 	if tokenPtr+1 != len(tokens) {
-		return nil, fmt.Errorf("incorrect format: expected 5 space-separated time fields followed by a command")
+		return nil, fmt.Errorf("incorrect format: expected %v space-separated time fields followed by a command", len(options.timeFields()))
 	}
 	return &root, nil
 }
@@ -64,6 +64,13 @@ func TestParseTimeVal(t *testing.T) {
 			1,
 			true,
 		},
+		{ // Check for names
+			[]Token{{TokenName, []rune("MON")}},
+			0,
+			AstNode{AstTimeName, "MON", []AstNode{}},
+			1,
+			true,
+		},
 		{ // Reject invalid range values
 			[]Token{{TokenComma, []rune(",")}},
 			0,
@@ -95,3 +102,157 @@ func TestParseTimeVal(t *testing.T) {
 		}
 	}
 }
+
+func TestParseTimeSteps(t *testing.T) {
+	tests := []struct {
+		inputTokens []Token
+		inputPtr    int
+
+		expectedNode    AstNode
+		expectedPtr     int
+		expectedSuccess bool
+	}{
+		{ // Steps over an asterisk
+			[]Token{{TokenAsterisk, []rune("*")}, {TokenSlash, []rune("/")}, {TokenNumber, []rune("15")}},
+			0,
+			AstNode{AstTimeSteps, "*/15", []AstNode{
+				{AstAsterisk, "*", []AstNode{}},
+				{AstTimeVal, "15", []AstNode{}},
+			}},
+			3,
+			true,
+		},
+		{ // Steps over a range
+			[]Token{
+				{TokenName, []rune("MON")}, {TokenDash, []rune("-")}, {TokenName, []rune("FRI")},
+				{TokenSlash, []rune("/")}, {TokenNumber, []rune("2")},
+			},
+			0,
+			AstNode{AstTimeSteps, "MON-FRI/2", []AstNode{
+				{AstTimeRange, "MON-FRI", []AstNode{
+					{AstTimeName, "MON", []AstNode{}},
+					{AstTimeName, "FRI", []AstNode{}},
+				}},
+				{AstTimeVal, "2", []AstNode{}},
+			}},
+			5,
+			true,
+		},
+		{ // Steps from a single value
+			[]Token{{TokenNumber, []rune("5")}, {TokenSlash, []rune("/")}, {TokenNumber, []rune("15")}},
+			0,
+			AstNode{AstTimeSteps, "5/15", []AstNode{
+				{AstTimeVal, "5", []AstNode{}},
+				{AstTimeVal, "15", []AstNode{}},
+			}},
+			3,
+			true,
+		},
+		{ // Reject steps which aren't a number
+			[]Token{{TokenNumber, []rune("5")}, {TokenSlash, []rune("/")}, {TokenAsterisk, []rune("*")}},
+			0,
+			AstNode{},
+			0,
+			false,
+		},
+		{ // Reject values without steps
+			[]Token{{TokenNumber, []rune("5")}, {TokenComma, []rune(",")}},
+			0,
+			AstNode{},
+			0,
+			false,
+		},
+	}
+
+	for i, test := range tests {
+		node, newPtr, success := parseTimeSteps(test.inputTokens, test.inputPtr)
+
+		// Should return the correct success value
+		if success != test.expectedSuccess {
+			t.Errorf("test %v, expected success to be %v, got %v", i, test.expectedSuccess, success)
+		}
+
+		if newPtr != test.expectedPtr {
+			t.Errorf("test %v, expected pointer %v, got %v", i, test.expectedPtr, newPtr)
+		}
+
+		// No need to test the value
+		if success == false {
+			continue
+		}
+
+		if !reflect.DeepEqual(node, test.expectedNode) {
+			t.Errorf("test %v, expected nodes to be %v, got %v", i, test.expectedNode, node)
+		}
+	}
+}
+
+func TestParseHashed(t *testing.T) {
+	tests := []struct {
+		inputTokens []Token
+		inputPtr    int
+
+		expectedNode    AstNode
+		expectedPtr     int
+		expectedSuccess bool
+	}{
+		{ // Hash over the whole field
+			[]Token{{TokenHashed, []rune("H")}, {TokenSpace, []rune(" ")}},
+			0,
+			AstNode{AstHashed, "H", []AstNode{{AstAsterisk, "*", []AstNode{}}}},
+			1,
+			true,
+		},
+		{ // Hash with a range and steps
+			[]Token{
+				{TokenHashed, []rune("H")}, {TokenOpenParen, []rune("(")},
+				{TokenNumber, []rune("0")}, {TokenDash, []rune("-")}, {TokenNumber, []rune("29")},
+				{TokenCloseParen, []rune(")")}, {TokenSlash, []rune("/")}, {TokenNumber, []rune("10")},
+				{TokenSpace, []rune(" ")},
+			},
+			0,
+			AstNode{AstHashed, "H(0-29)/10", []AstNode{
+				{AstTimeRange, "0-29", []AstNode{
+					{AstTimeVal, "0", []AstNode{}},
+					{AstTimeVal, "29", []AstNode{}},
+				}},
+				{AstTimeVal, "10", []AstNode{}},
+			}},
+			8,
+			true,
+		},
+		{ // Reject unclosed ranges
+			[]Token{
+				{TokenHashed, []rune("H")}, {TokenOpenParen, []rune("(")},
+				{TokenNumber, []rune("0")}, {TokenDash, []rune("-")}, {TokenNumber, []rune("29")},
+				{TokenSpace, []rune(" ")},
+			},
+			0,
+			AstNode{},
+			0,
+			false,
+		},
+	}
+
+	for i, test := range tests {
+		node, newPtr, success := parseHashed(test.inputTokens, test.inputPtr)
+
+		// Should return the correct success value
+		if success != test.expectedSuccess {
+			t.Errorf("test %v, expected success to be %v, got %v", i, test.expectedSuccess, success)
+		}
+
+		if newPtr != test.expectedPtr {
+			t.Errorf("test %v, expected pointer %v, got %v", i, test.expectedPtr, newPtr)
+		}
+
+		// No need to test the value
+		if success == false {
+			continue
+		}
+
+		if !reflect.DeepEqual(node, test.expectedNode) {
+			t.Errorf("test %v, expected nodes to be %v, got %v", i, test.expectedNode, node)
+		}
+	}
+}
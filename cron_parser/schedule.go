@@ -0,0 +1,361 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// searchYears bounds the search for a run time, the Gregorian calendar repeats
+// every 400 years so a schedule which doesn't fire within them never fires
+const searchYears = 400
+
+// nextIn returns the smallest value of a sorted slice which isn't below from,
+// ok is false when there isn't one
+func nextIn(values []int, from int) (value int, ok bool) {
+	i := sort.SearchInts(values, from)
+	if i == len(values) {
+		return 0, false
+	}
+	return values[i], true
+}
+
+// prevIn returns the largest value of a sorted slice which isn't above from,
+// ok is false when there isn't one
+func prevIn(values []int, from int) (value int, ok bool) {
+	i := sort.SearchInts(values, from+1)
+	if i == 0 {
+		return 0, false
+	}
+	return values[i-1], true
+}
+
+// wallClock returns the date and time of day of a time as if it was in UTC,
+// so that it can be stepped through without daylight saving time changes
+func wallClock(t time.Time) time.Time {
+	year, month, day := t.Date()
+	hour, minute, second := t.Clock()
+	return time.Date(year, month, day, hour, minute, second, 0, time.UTC)
+}
+
+// checkSchedule reports tasks which can't be stepped through run by run
+func (t CronTask) checkSchedule() error {
+	if t.Event != "" {
+		return fmt.Errorf("the task runs on the %v event rather than on a schedule", t.Event)
+	}
+	if t.Interval != 0 {
+		return nil
+	}
+	if len(t.Minutes) == 0 || len(t.Hours) == 0 || len(t.Months) == 0 || (t.Seconds != nil && len(t.Seconds) == 0) {
+		return fmt.Errorf("the schedule never fires, one of its time fields has no values")
+	}
+	return nil
+}
+
+// step is the resolution of the schedule, a second when it has a seconds field
+// and a minute otherwise
+func (t CronTask) step() time.Duration {
+	if t.Seconds != nil {
+		return time.Second
+	}
+	return time.Minute
+}
+
+// intervalRuns counts the whole intervals since the Unix epoch up to the given
+// time, rounding down also before the epoch, and reports whether the time is a
+// run time
+func (t CronTask) intervalRuns(at time.Time) (runs time.Duration, onRun bool) {
+	elapsed := at.Sub(time.Unix(0, 0))
+	runs = elapsed / t.Interval
+	if elapsed%t.Interval < 0 {
+		runs--
+	}
+	return runs, elapsed%t.Interval == 0
+}
+
+// intervalRun returns the time of the given run of an interval task
+func (t CronTask) intervalRun(runs time.Duration, loc *time.Location) time.Time {
+	return time.Unix(0, 0).Add(runs * t.Interval).In(loc)
+}
+
+// location is the time zone the schedule is followed in
+func (t CronTask) location(fallback time.Time) *time.Location {
+	if t.Location != nil {
+		return t.Location
+	}
+	return fallback.Location()
+}
+
+// Next returns the first time strictly after the given one the task runs at,
+// to the minute, or to the second when the task has a seconds field. The
+// schedule is followed in the task's time zone, or in the time zone of after
+// when it doesn't have one, and times skipped by a daylight saving time change
+// don't run. Tasks with an interval run at every multiple of it since the Unix
+// epoch. An error is returned for tasks which run on an event, and for
+// schedules which never fire again
+func (t CronTask) Next(after time.Time) (time.Time, error) {
+	if err := t.checkSchedule(); err != nil {
+		return time.Time{}, err
+	}
+	if t.Interval != 0 {
+		runs, _ := t.intervalRuns(after)
+		return t.intervalRun(runs+1, after.Location()), nil
+	}
+
+	loc := t.location(after)
+	step := t.step()
+	lastYear := after.In(loc).Year() + searchYears
+	if t.Years != nil {
+		lastYear = min(lastYear, t.Years[len(t.Years)-1])
+	}
+
+	wall := wallClock(after.In(loc)).Truncate(step).Add(step)
+	for wall.Year() <= lastYear {
+		year, month, day := wall.Date()
+		hour, minute, second := wall.Clock()
+
+		if t.Years != nil {
+			next, ok := nextIn(t.Years, year)
+			if !ok {
+				break
+			}
+			if next != year {
+				wall = time.Date(next, time.January, 1, 0, 0, 0, 0, time.UTC)
+				continue
+			}
+		}
+		if next, ok := nextIn(t.Months, int(month)); !ok {
+			wall = time.Date(year+1, time.January, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		} else if next != int(month) {
+			wall = time.Date(year, time.Month(next), 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !t.matchesDay(year, month, day) {
+			wall = time.Date(year, month, day+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if next, ok := nextIn(t.Hours, hour); !ok {
+			wall = time.Date(year, month, day+1, 0, 0, 0, 0, time.UTC)
+			continue
+		} else if next != hour {
+			wall = time.Date(year, month, day, next, 0, 0, 0, time.UTC)
+			continue
+		}
+		if next, ok := nextIn(t.Minutes, minute); !ok {
+			wall = time.Date(year, month, day, hour+1, 0, 0, 0, time.UTC)
+			continue
+		} else if next != minute {
+			wall = time.Date(year, month, day, hour, next, 0, 0, time.UTC)
+			continue
+		}
+		if t.Seconds != nil {
+			if next, ok := nextIn(t.Seconds, second); !ok {
+				wall = time.Date(year, month, day, hour, minute+1, 0, 0, time.UTC)
+				continue
+			} else if next != second {
+				wall = time.Date(year, month, day, hour, minute, next, 0, time.UTC)
+				continue
+			}
+		}
+
+		// The wall clock time doesn't exist when it falls in a daylight saving
+		// time gap, and its second occurrence is skipped when clocks go back
+		run := time.Date(year, month, day, hour, minute, second, 0, loc)
+		if wallClock(run).Equal(wall) && run.After(after) {
+			return run, nil
+		}
+		wall = wall.Add(step)
+	}
+	return time.Time{}, fmt.Errorf("the schedule never fires after %v", after.Format(time.RFC3339))
+}
+
+// Prev returns the last time strictly before the given one the task ran at,
+// following the same rules as Next. An error is returned for tasks which run
+// on an event, and for schedules which never fired before
+func (t CronTask) Prev(before time.Time) (time.Time, error) {
+	if err := t.checkSchedule(); err != nil {
+		return time.Time{}, err
+	}
+	if t.Interval != 0 {
+		runs, onRun := t.intervalRuns(before)
+		if onRun {
+			runs--
+		}
+		return t.intervalRun(runs, before.Location()), nil
+	}
+
+	loc := t.location(before)
+	step := t.step()
+	firstYear := before.In(loc).Year() - searchYears
+	if t.Years != nil {
+		firstYear = max(firstYear, t.Years[0])
+	}
+
+	// Each field which doesn't match moves to the last run time of the
+	// previous value, one step before the start of the next one
+	wall := wallClock(before.In(loc)).Truncate(step)
+	for wall.Year() >= firstYear {
+		year, month, day := wall.Date()
+		hour, minute, second := wall.Clock()
+
+		if t.Years != nil {
+			prev, ok := prevIn(t.Years, year)
+			if !ok {
+				break
+			}
+			if prev != year {
+				wall = time.Date(prev+1, time.January, 1, 0, 0, 0, 0, time.UTC).Add(-step)
+				continue
+			}
+		}
+		if prev, ok := prevIn(t.Months, int(month)); !ok {
+			wall = time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC).Add(-step)
+			continue
+		} else if prev != int(month) {
+			wall = time.Date(year, time.Month(prev+1), 1, 0, 0, 0, 0, time.UTC).Add(-step)
+			continue
+		}
+		if !t.matchesDay(year, month, day) {
+			wall = time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Add(-step)
+			continue
+		}
+		if prev, ok := prevIn(t.Hours, hour); !ok {
+			wall = time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Add(-step)
+			continue
+		} else if prev != hour {
+			wall = time.Date(year, month, day, prev+1, 0, 0, 0, time.UTC).Add(-step)
+			continue
+		}
+		if prev, ok := prevIn(t.Minutes, minute); !ok {
+			wall = time.Date(year, month, day, hour, 0, 0, 0, time.UTC).Add(-step)
+			continue
+		} else if prev != minute {
+			wall = time.Date(year, month, day, hour, prev+1, 0, 0, time.UTC).Add(-step)
+			continue
+		}
+		if t.Seconds != nil {
+			if prev, ok := prevIn(t.Seconds, second); !ok {
+				wall = time.Date(year, month, day, hour, minute, 0, 0, time.UTC).Add(-step)
+				continue
+			} else if prev != second {
+				wall = time.Date(year, month, day, hour, minute, prev, 0, time.UTC)
+				continue
+			}
+		}
+
+		run := time.Date(year, month, day, hour, minute, second, 0, loc)
+		if wallClock(run).Equal(wall) && run.Before(before) {
+			return run, nil
+		}
+		wall = wall.Add(-step)
+	}
+	return time.Time{}, fmt.Errorf("the schedule never fired before %v", before.Format(time.RFC3339))
+}
+
+// RunIterator steps through the run times of a task within a time window, see
+// CronTask.Runs
+type RunIterator struct {
+	task  CronTask
+	run   time.Time
+	end   time.Time
+	limit int
+	count int
+	done  bool
+	err   error
+}
+
+// RunsOption configures a RunIterator
+type RunsOption func(*RunIterator)
+
+// WithLimit stops a RunIterator after n run times, n of 0 means no limit
+func WithLimit(n int) RunsOption {
+	return func(it *RunIterator) {
+		it.limit = n
+	}
+}
+
+// Runs iterates over the run times of the task from start, included, up to
+// end, excluded. Each run time is only found when asked for, so the window can
+// be as long as needed:
+//
+//	runs := task.Runs(start, end, WithLimit(10))
+//	for runs.Next() {
+//		fmt.Println(runs.Time())
+//	}
+//	if err := runs.Err(); err != nil {
+//		...
+//	}
+func (t CronTask) Runs(start time.Time, end time.Time, opts ...RunsOption) *RunIterator {
+	it := &RunIterator{task: t, run: start.Add(-time.Nanosecond), end: end}
+	for _, opt := range opts {
+		opt(it)
+	}
+	if it.err = t.checkSchedule(); it.err != nil {
+		it.done = true
+	}
+	return it
+}
+
+// Next moves to the next run time, it returns false once there are no more
+// run times in the window or the limit is reached
+func (it *RunIterator) Next() bool {
+	if it.done || (it.limit > 0 && it.count >= it.limit) {
+		it.done = true
+		return false
+	}
+	// An error at this point means the schedule doesn't fire again, which
+	// just ends the window early
+	run, err := it.task.Next(it.run)
+	if err != nil || !run.Before(it.end) {
+		it.done = true
+		return false
+	}
+	it.run = run
+	it.count++
+	return true
+}
+
+// Time is the run time Next moved to
+func (it *RunIterator) Time() time.Time {
+	return it.run
+}
+
+// Err reports why the task's run times couldn't be iterated over
+func (it *RunIterator) Err() error {
+	return it.err
+}
+
+// Matches checks whether the task runs at the given time, truncated to the
+// minute, or to the second when the task has a seconds field. It follows the
+// same rules as Next, so it never matches tasks which run on an event, wall
+// clock times repeated when clocks go back only match the first time and tasks
+// with an interval match every multiple of it since the Unix epoch
+func (t CronTask) Matches(at time.Time) bool {
+	if t.checkSchedule() != nil {
+		return false
+	}
+	if t.Interval != 0 {
+		_, onRun := t.intervalRuns(at.Truncate(time.Second))
+		return onRun
+	}
+
+	loc := t.location(at)
+	at = at.In(loc)
+	if !t.MatchesDate(at) {
+		return false
+	}
+	hour, minute, second := at.Clock()
+	if !IntSliceContains(t.Hours, hour) || !IntSliceContains(t.Minutes, minute) {
+		return false
+	}
+	if t.Seconds != nil && !IntSliceContains(t.Seconds, second) {
+		return false
+	}
+	if t.Seconds == nil {
+		second = 0
+	}
+	year, month, day := at.Date()
+	run := time.Date(year, month, day, hour, minute, second, 0, loc)
+	return !run.After(at) && at.Sub(run) < t.step()
+}
@@ -0,0 +1,419 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		inputCronStr  string
+		inputOpts     []CompileOption
+		inputAfter    time.Time
+		expected      time.Time
+		expectedError error
+	}{
+		// Strictly after, even when the given time is a run time
+		{"*/15 * * * * cmd", nil,
+			time.Date(2024, time.June, 13, 10, 15, 0, 0, time.UTC),
+			time.Date(2024, time.June, 13, 10, 30, 0, 0, time.UTC), nil},
+		// Seconds within the minute are dropped
+		{"* * * * * cmd", nil,
+			time.Date(2024, time.June, 13, 10, 15, 59, 999, time.UTC),
+			time.Date(2024, time.June, 13, 10, 16, 0, 0, time.UTC), nil},
+		// Rolls over into the next year
+		{"0 0 1 1 * cmd", nil,
+			time.Date(2024, time.June, 13, 10, 15, 0, 0, time.UTC),
+			time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC), nil},
+		// Months without a 31st are skipped
+		{"0 12 31 * * cmd", nil,
+			time.Date(2024, time.March, 31, 12, 0, 0, 0, time.UTC),
+			time.Date(2024, time.May, 31, 12, 0, 0, 0, time.UTC), nil},
+		// The 29th of February waits for the next leap year
+		{"0 0 29 2 * cmd", nil,
+			time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC), nil},
+		// 2100 isn't a leap year
+		{"0 0 29 2 * cmd", nil,
+			time.Date(2097, time.March, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2104, time.February, 29, 0, 0, 0, 0, time.UTC), nil},
+		// Either the 29th of February or any Monday in February
+		{"0 0 29 2 1 cmd", nil,
+			time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2025, time.February, 3, 0, 0, 0, 0, time.UTC), nil},
+		// Either the 13th or a Friday
+		{"0 0 13 * 5 cmd", nil,
+			time.Date(2024, time.June, 8, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, time.June, 13, 0, 0, 0, 0, time.UTC), nil},
+		// Day rules
+		{"0 0 L * * cmd", nil,
+			time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC), nil},
+		{"0 9 * * 5L cmd", nil,
+			time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, time.June, 28, 9, 0, 0, 0, time.UTC), nil},
+		// Seconds field
+		{"*/20 0 12 * * * cmd", []CompileOption{WithSeconds()},
+			time.Date(2024, time.June, 13, 12, 0, 40, 0, time.UTC),
+			time.Date(2024, time.June, 14, 12, 0, 0, 0, time.UTC), nil},
+		// Year field
+		{"0 0 1 1 * 2030 cmd", []CompileOption{WithYear()},
+			time.Date(2024, time.June, 13, 0, 0, 0, 0, time.UTC),
+			time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC), nil},
+		// The schedule is followed in the task's time zone
+		{"CRON_TZ=America/New_York 0 9 * * * cmd", nil,
+			time.Date(2024, time.June, 13, 12, 0, 0, 0, time.UTC),
+			time.Date(2024, time.June, 13, 9, 0, 0, 0, newYork), nil},
+		// The schedule is followed in the time zone of the given time
+		{"0 9 * * * cmd", nil,
+			time.Date(2024, time.June, 13, 10, 0, 0, 0, newYork),
+			time.Date(2024, time.June, 14, 9, 0, 0, 0, newYork), nil},
+		// 02:30 doesn't exist on the day clocks go forward
+		{"30 2 * * * cmd", nil,
+			time.Date(2024, time.March, 9, 3, 0, 0, 0, newYork),
+			time.Date(2024, time.March, 11, 2, 30, 0, 0, newYork), nil},
+		// 01:30 happens twice on the day clocks go back, only the first one runs
+		{"30 1 * * * cmd", nil,
+			time.Date(2024, time.November, 3, 1, 0, 0, 0, newYork),
+			time.Date(2024, time.November, 3, 1, 30, 0, 0, newYork), nil},
+		{"30 1 * * * cmd", nil,
+			time.Date(2024, time.November, 3, 1, 30, 0, 0, newYork),
+			time.Date(2024, time.November, 4, 1, 30, 0, 0, newYork), nil},
+		// Intervals without a start line up with the Unix epoch
+		{"@every 1h30m cmd", nil,
+			time.Date(2024, time.June, 13, 10, 15, 0, 0, time.UTC),
+			time.Date(2024, time.June, 13, 10, 30, 0, 0, time.UTC), nil},
+		{"@every 1h cmd", nil,
+			time.Date(1960, time.January, 1, 0, 30, 0, 0, time.UTC),
+			time.Date(1960, time.January, 1, 1, 0, 0, 0, time.UTC), nil},
+		{"@every 1h cmd", nil,
+			time.Date(1960, time.January, 1, 1, 0, 0, 0, time.UTC),
+			time.Date(1960, time.January, 1, 2, 0, 0, 0, time.UTC), nil},
+		{"@reboot cmd", nil,
+			time.Date(2024, time.June, 13, 10, 15, 0, 0, time.UTC),
+			time.Time{}, fmt.Errorf("the task runs on the reboot event rather than on a schedule")},
+		{"0 0 30 2 * cmd", nil,
+			time.Date(2024, time.June, 13, 10, 15, 0, 0, time.UTC),
+			time.Time{}, fmt.Errorf("the schedule never fires after 2024-06-13T10:15:00Z")},
+		{"0 0 1 1 * 2025 cmd", []CompileOption{WithYear()},
+			time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+			time.Time{}, fmt.Errorf("the schedule never fires after 2025-01-01T00:00:00Z")},
+	}
+
+	for i, test := range tests {
+		task, err := CronTaskCompile(test.inputCronStr, test.inputOpts...)
+		if err != nil {
+			t.Errorf("test %v, expected no error, got %v", i, err)
+			continue
+		}
+		res, err := task.Next(test.inputAfter)
+
+		if fmt.Sprint(err) != fmt.Sprint(test.expectedError) {
+			t.Errorf("test %v, expected error %v, got %v", i, test.expectedError, err)
+		}
+		if !res.Equal(test.expected) {
+			t.Errorf("test %v, expected %v, got %v", i, test.expected, res)
+		}
+	}
+}
+
+func TestNextEmptyField(t *testing.T) {
+	task := CronTask{Minutes: []int{0}, Months: []int{1}}
+	_, err := task.Next(time.Date(2024, time.June, 13, 0, 0, 0, 0, time.UTC))
+	expectedError := fmt.Errorf("the schedule never fires, one of its time fields has no values")
+	if fmt.Sprint(err) != fmt.Sprint(expectedError) {
+		t.Errorf("expected error %v, got %v", expectedError, err)
+	}
+}
+
+func TestPrev(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		inputCronStr  string
+		inputOpts     []CompileOption
+		inputBefore   time.Time
+		expected      time.Time
+		expectedError error
+	}{
+		// Strictly before, even when the given time is a run time
+		{"*/15 * * * * cmd", nil,
+			time.Date(2024, time.June, 13, 10, 15, 0, 0, time.UTC),
+			time.Date(2024, time.June, 13, 10, 0, 0, 0, time.UTC), nil},
+		// A run time within the same minute counts
+		{"* * * * * cmd", nil,
+			time.Date(2024, time.June, 13, 10, 15, 0, 1, time.UTC),
+			time.Date(2024, time.June, 13, 10, 15, 0, 0, time.UTC), nil},
+		// Last run before 03:00
+		{"30 1,4 * * * cmd", nil,
+			time.Date(2024, time.June, 13, 3, 0, 0, 0, time.UTC),
+			time.Date(2024, time.June, 13, 1, 30, 0, 0, time.UTC), nil},
+		// Rolls back into the previous year
+		{"0 0 31 12 * cmd", nil,
+			time.Date(2024, time.June, 13, 10, 15, 0, 0, time.UTC),
+			time.Date(2023, time.December, 31, 0, 0, 0, 0, time.UTC), nil},
+		// Months without a 31st are skipped
+		{"0 12 31 * * cmd", nil,
+			time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, time.March, 31, 12, 0, 0, 0, time.UTC), nil},
+		// The 29th of February goes back to the previous leap year
+		{"0 0 29 2 * cmd", nil,
+			time.Date(2024, time.February, 28, 0, 0, 0, 0, time.UTC),
+			time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC), nil},
+		// 1900 isn't a leap year
+		{"0 0 29 2 * cmd", nil,
+			time.Date(1904, time.February, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(1896, time.February, 29, 0, 0, 0, 0, time.UTC), nil},
+		// Either the 13th or a Friday
+		{"0 0 13 * 5 cmd", nil,
+			time.Date(2024, time.June, 13, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, time.June, 7, 0, 0, 0, 0, time.UTC), nil},
+		// Odd days which are also Fridays
+		{"0 0 */2 * 5 cmd", nil,
+			time.Date(2024, time.June, 13, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, time.June, 7, 0, 0, 0, 0, time.UTC), nil},
+		// Day rules
+		{"0 0 L * * cmd", nil,
+			time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC), nil},
+		{"0 9 * * 5L cmd", nil,
+			time.Date(2024, time.June, 28, 9, 0, 0, 0, time.UTC),
+			time.Date(2024, time.May, 31, 9, 0, 0, 0, time.UTC), nil},
+		// Seconds field
+		{"*/20 0 12 * * * cmd", []CompileOption{WithSeconds()},
+			time.Date(2024, time.June, 13, 12, 0, 0, 0, time.UTC),
+			time.Date(2024, time.June, 12, 12, 0, 40, 0, time.UTC), nil},
+		// Year field
+		{"0 0 1 1 * 2020 cmd", []CompileOption{WithYear()},
+			time.Date(2024, time.June, 13, 0, 0, 0, 0, time.UTC),
+			time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC), nil},
+		// The schedule is followed in the task's time zone
+		{"CRON_TZ=America/New_York 0 9 * * * cmd", nil,
+			time.Date(2024, time.June, 13, 12, 0, 0, 0, time.UTC),
+			time.Date(2024, time.June, 12, 9, 0, 0, 0, newYork), nil},
+		// 02:30 doesn't exist on the day clocks go forward
+		{"30 2 * * * cmd", nil,
+			time.Date(2024, time.March, 10, 12, 0, 0, 0, newYork),
+			time.Date(2024, time.March, 9, 2, 30, 0, 0, newYork), nil},
+		// Only the first 01:30 runs on the day clocks go back
+		{"30 1 * * * cmd", nil,
+			time.Date(2024, time.November, 3, 12, 0, 0, 0, newYork),
+			time.Date(2024, time.November, 3, 1, 30, 0, 0, newYork), nil},
+		// Intervals without a start line up with the Unix epoch
+		{"@every 1h30m cmd", nil,
+			time.Date(2024, time.June, 13, 10, 30, 0, 0, time.UTC),
+			time.Date(2024, time.June, 13, 9, 0, 0, 0, time.UTC), nil},
+		{"@every 1h cmd", nil,
+			time.Date(1960, time.January, 1, 0, 30, 0, 0, time.UTC),
+			time.Date(1960, time.January, 1, 0, 0, 0, 0, time.UTC), nil},
+		{"@every 1h cmd", nil,
+			time.Date(1960, time.January, 1, 1, 0, 0, 0, time.UTC),
+			time.Date(1960, time.January, 1, 0, 0, 0, 0, time.UTC), nil},
+		{"@reboot cmd", nil,
+			time.Date(2024, time.June, 13, 10, 15, 0, 0, time.UTC),
+			time.Time{}, fmt.Errorf("the task runs on the reboot event rather than on a schedule")},
+		{"0 0 30 2 * cmd", nil,
+			time.Date(2024, time.June, 13, 10, 15, 0, 0, time.UTC),
+			time.Time{}, fmt.Errorf("the schedule never fired before 2024-06-13T10:15:00Z")},
+		{"0 0 1 1 * 2025 cmd", []CompileOption{WithYear()},
+			time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+			time.Time{}, fmt.Errorf("the schedule never fired before 2025-01-01T00:00:00Z")},
+	}
+
+	for i, test := range tests {
+		task, err := CronTaskCompile(test.inputCronStr, test.inputOpts...)
+		if err != nil {
+			t.Errorf("test %v, expected no error, got %v", i, err)
+			continue
+		}
+		res, err := task.Prev(test.inputBefore)
+
+		if fmt.Sprint(err) != fmt.Sprint(test.expectedError) {
+			t.Errorf("test %v, expected error %v, got %v", i, test.expectedError, err)
+		}
+		if !res.Equal(test.expected) {
+			t.Errorf("test %v, expected %v, got %v", i, test.expected, res)
+		}
+	}
+}
+
+func TestPrevMirrorsNext(t *testing.T) {
+	tests := []string{"*/7 3-5 * * * cmd", "0 0 13 * 5 cmd", "0 9 1W * * cmd", "0 0 29 2 * cmd"}
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, test := range tests {
+		task, err := CronTaskCompile(test)
+		if err != nil {
+			t.Errorf("test %v, expected no error, got %v", test, err)
+			continue
+		}
+		run := start
+		for i := 0; i < 50; i++ {
+			next, err := task.Next(run)
+			if err != nil {
+				t.Errorf("test %v, expected no error, got %v", test, err)
+				break
+			}
+			if i > 0 {
+				prev, err := task.Prev(next)
+				if err != nil || !prev.Equal(run) {
+					t.Errorf("test %v, expected the run before %v to be %v, got %v (%v)", test, next, run, prev, err)
+				}
+			}
+			run = next
+		}
+	}
+}
+
+func TestRuns(t *testing.T) {
+	tests := []struct {
+		inputCronStr  string
+		inputCompile  []CompileOption
+		inputStart    time.Time
+		inputEnd      time.Time
+		inputOpts     []RunsOption
+		expected      []time.Time
+		expectedError error
+	}{
+		// The start is included and the end excluded
+		{"0 12 * * * cmd", nil,
+			time.Date(2024, time.February, 28, 12, 0, 0, 0, time.UTC),
+			time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC),
+			nil,
+			[]time.Time{
+				time.Date(2024, time.February, 28, 12, 0, 0, 0, time.UTC),
+				time.Date(2024, time.February, 29, 12, 0, 0, 0, time.UTC),
+			}, nil},
+		{"*/10 * * * * cmd", nil,
+			time.Date(2024, time.June, 13, 10, 0, 0, 0, time.UTC),
+			time.Date(2024, time.June, 14, 0, 0, 0, 0, time.UTC),
+			[]RunsOption{WithLimit(3)},
+			[]time.Time{
+				time.Date(2024, time.June, 13, 10, 0, 0, 0, time.UTC),
+				time.Date(2024, time.June, 13, 10, 10, 0, 0, time.UTC),
+				time.Date(2024, time.June, 13, 10, 20, 0, 0, time.UTC),
+			}, nil},
+		// No runs within the window
+		{"0 0 1 1 * cmd", nil,
+			time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, time.July, 1, 0, 0, 0, 0, time.UTC),
+			nil, []time.Time{}, nil},
+		// The schedule stops firing before the end of the window
+		{"0 0 1 1 * 2025 cmd", []CompileOption{WithYear()},
+			time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC),
+			nil,
+			[]time.Time{time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)}, nil},
+		// Intervals line up with the Unix epoch as they do for Next
+		{"@every 1h30m cmd", nil,
+			time.Date(2024, time.June, 13, 10, 15, 0, 0, time.UTC),
+			time.Date(2024, time.June, 13, 14, 0, 0, 0, time.UTC),
+			nil,
+			[]time.Time{
+				time.Date(2024, time.June, 13, 10, 30, 0, 0, time.UTC),
+				time.Date(2024, time.June, 13, 12, 0, 0, 0, time.UTC),
+				time.Date(2024, time.June, 13, 13, 30, 0, 0, time.UTC),
+			}, nil},
+		{"@every 1h cmd", nil,
+			time.Date(1960, time.January, 1, 0, 30, 0, 0, time.UTC),
+			time.Date(1960, time.January, 1, 3, 0, 0, 0, time.UTC),
+			nil,
+			[]time.Time{
+				time.Date(1960, time.January, 1, 1, 0, 0, 0, time.UTC),
+				time.Date(1960, time.January, 1, 2, 0, 0, 0, time.UTC),
+			}, nil},
+		{"@reboot cmd", nil,
+			time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, time.July, 1, 0, 0, 0, 0, time.UTC),
+			nil, []time.Time{}, fmt.Errorf("the task runs on the reboot event rather than on a schedule")},
+	}
+
+	for i, test := range tests {
+		task, err := CronTaskCompile(test.inputCronStr, test.inputCompile...)
+		if err != nil {
+			t.Errorf("test %v, expected no error, got %v", i, err)
+			continue
+		}
+		res := []time.Time{}
+		runs := task.Runs(test.inputStart, test.inputEnd, test.inputOpts...)
+		for runs.Next() {
+			res = append(res, runs.Time())
+		}
+
+		if fmt.Sprint(runs.Err()) != fmt.Sprint(test.expectedError) {
+			t.Errorf("test %v, expected error %v, got %v", i, test.expectedError, runs.Err())
+		}
+		if !reflect.DeepEqual(res, test.expected) {
+			t.Errorf("test %v, expected %v, got %v", i, test.expected, res)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		inputCronStr string
+		inputOpts    []CompileOption
+		inputTime    time.Time
+		expected     bool
+	}{
+		{"*/15 9-17 * * 1-5 cmd", nil, time.Date(2024, time.June, 13, 9, 45, 0, 0, time.UTC), true},
+		// Truncated to the minute
+		{"*/15 9-17 * * 1-5 cmd", nil, time.Date(2024, time.June, 13, 9, 45, 59, 999, time.UTC), true},
+		{"*/15 9-17 * * 1-5 cmd", nil, time.Date(2024, time.June, 13, 9, 46, 0, 0, time.UTC), false},
+		{"*/15 9-17 * * 1-5 cmd", nil, time.Date(2024, time.June, 13, 18, 0, 0, 0, time.UTC), false},
+		// A Saturday
+		{"*/15 9-17 * * 1-5 cmd", nil, time.Date(2024, time.June, 15, 9, 45, 0, 0, time.UTC), false},
+		// Either the 13th or a Friday
+		{"0 0 13 * 5 cmd", nil, time.Date(2024, time.June, 13, 0, 0, 0, 0, time.UTC), true},
+		{"0 0 13 * 5 cmd", nil, time.Date(2024, time.June, 14, 0, 0, 0, 0, time.UTC), true},
+		{"0 0 13 * 5 cmd", nil, time.Date(2024, time.June, 15, 0, 0, 0, 0, time.UTC), false},
+		// Odd days which are also Fridays
+		{"0 0 */2 * 5 cmd", nil, time.Date(2024, time.June, 13, 0, 0, 0, 0, time.UTC), false},
+		{"0 0 */2 * 5 cmd", nil, time.Date(2024, time.June, 7, 0, 0, 0, 0, time.UTC), true},
+		{"0 0 29 2 * cmd", nil, time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC), true},
+		{"0 0 L * * cmd", nil, time.Date(2023, time.February, 28, 0, 0, 0, 0, time.UTC), true},
+		{"*/20 0 12 * * * cmd", []CompileOption{WithSeconds()}, time.Date(2024, time.June, 13, 12, 0, 40, 5, time.UTC), true},
+		{"*/20 0 12 * * * cmd", []CompileOption{WithSeconds()}, time.Date(2024, time.June, 13, 12, 0, 41, 0, time.UTC), false},
+		{"0 0 1 1 * 2025 cmd", []CompileOption{WithYear()}, time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC), true},
+		{"0 0 1 1 * 2025 cmd", []CompileOption{WithYear()}, time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC), false},
+		// The time is taken in the task's time zone
+		{"CRON_TZ=America/New_York 0 9 * * * cmd", nil, time.Date(2024, time.June, 13, 13, 0, 0, 0, time.UTC), true},
+		{"CRON_TZ=America/New_York 0 9 * * * cmd", nil, time.Date(2024, time.June, 13, 9, 0, 0, 0, time.UTC), false},
+		// Only the first 01:30 runs on the day clocks go back
+		{"30 1 * * * cmd", nil, time.Date(2024, time.November, 3, 5, 30, 0, 0, time.UTC).In(newYork), true},
+		{"30 1 * * * cmd", nil, time.Date(2024, time.November, 3, 6, 30, 0, 0, time.UTC).In(newYork), false},
+		{"@every 1h30m cmd", nil, time.Date(2024, time.June, 13, 10, 30, 0, 0, time.UTC), true},
+		{"@every 1h30m cmd", nil, time.Date(2024, time.June, 13, 10, 0, 0, 0, time.UTC), false},
+		{"@every 1h cmd", nil, time.Date(1960, time.January, 1, 1, 0, 0, 500, time.UTC), true},
+		{"@every 1h cmd", nil, time.Date(1960, time.January, 1, 0, 30, 0, 0, time.UTC), false},
+		{"@reboot cmd", nil, time.Date(2024, time.June, 13, 10, 0, 0, 0, time.UTC), false},
+	}
+
+	for i, test := range tests {
+		task, err := CronTaskCompile(test.inputCronStr, test.inputOpts...)
+		if err != nil {
+			t.Errorf("test %v, expected no error, got %v", i, err)
+			continue
+		}
+		res := task.Matches(test.inputTime)
+
+		if res != test.expected {
+			t.Errorf("test %v, expected %v, got %v", i, test.expected, res)
+		}
+	}
+}
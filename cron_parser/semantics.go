@@ -2,31 +2,202 @@ package main
 
 import (
 	"fmt"
+	"hash/fnv"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type CronTask struct {
+	// Seconds is only set when the cron string has a seconds field
+	Seconds     []int
 	Minutes     []int
 	Hours       []int
 	DaysOfMonth []int
-	Months      []int
-	DaysOfWeek  []int
-	Command     string
+	// DaysOfMonthRules holds the day of month constraints which depend on the
+	// month, such as the last day of the month (L) or the nearest weekday (15W)
+	DaysOfMonthRules []DayRule
+	Months           []int
+	DaysOfWeek       []int
+	// DaysOfWeekRules holds the day of week constraints which depend on the
+	// month, such as the first Monday (1#1) or the last Friday (5L)
+	DaysOfWeekRules []DayRule
+	// DaysOfWeekExpr is the day of week field as it was written, only kept
+	// when it spells Sunday as 7 which DaysOfWeek stores as 0
+	DaysOfWeekExpr string
+	// DaysOfMonthUnrestricted and DaysOfWeekUnrestricted record whether the day
+	// fields were written starting with an asterisk, which decides how the two
+	// fields combine, see MatchesDate
+	DaysOfMonthUnrestricted bool
+	DaysOfWeekUnrestricted  bool
+	// MatchBothDays makes both day fields match even when they're restricted,
+	// as in systemd calendar events
+	MatchBothDays bool
+	// Years is only set when the cron string has a year field
+	Years []int
+	// Event names the trigger of a task which doesn't run on a schedule (e.g. reboot)
+	Event string
+	// Interval is the time between the runs of a task which repeats at a fixed
+	// rate rather than on the time fields, e.g. rate(5 minutes)
+	Interval time.Duration
+	// Location is the time zone the schedule is in, nil unless the cron string
+	// starts with a CRON_TZ= or TZ= assignment
+	Location *time.Location
+	// User is the user the command runs as, only set for system crontabs
+	User    string
+	Command string
+	// Stdin is the text sent to the command's standard input, written after
+	// the first unescaped % of the command with each further % as a newline
+	Stdin string
 }
 
 func (t CronTask) String() string {
 	var sb strings.Builder
+	if t.Event != "" || t.Interval != 0 {
+		if t.Event != "" {
+			sb.WriteString(fmt.Sprintf("%-14s %v\n", "event", t.Event))
+		} else {
+			sb.WriteString(fmt.Sprintf("%-14s %v\n", "interval", t.Interval))
+		}
+		if t.Location != nil {
+			sb.WriteString(fmt.Sprintf("%-14s %v\n", "timezone", t.Location))
+		}
+		if t.User != "" {
+			sb.WriteString(fmt.Sprintf("%-14s %v\n", "user", t.User))
+		}
+		// Schedules parsed on their own don't have a command
+		if t.Command != "" {
+			sb.WriteString(fmt.Sprintf("%-14s %v\n", "command", t.Command))
+		}
+		if t.Stdin != "" {
+			sb.WriteString(fmt.Sprintf("%-14s %q\n", "stdin", t.Stdin))
+		}
+		return sb.String()
+	}
+	if t.Seconds != nil {
+		sb.WriteString(fmt.Sprintf("%-14s %v\n", "second", IntSliceToString(t.Seconds)))
+	}
 	sb.WriteString(fmt.Sprintf("%-14v %v\n", "minute", IntSliceToString(t.Minutes)))
 	sb.WriteString(fmt.Sprintf("%-14s %v\n", "hour", IntSliceToString(t.Hours)))
-	sb.WriteString(fmt.Sprintf("%-14s %v\n", "day of month", IntSliceToString(t.DaysOfMonth)))
+	sb.WriteString(fmt.Sprintf("%-14s %v\n", "day of month", DaysToString(t.DaysOfMonth, t.DaysOfMonthRules)))
 	sb.WriteString(fmt.Sprintf("%-14s %v\n", "month", IntSliceToString(t.Months)))
-	sb.WriteString(fmt.Sprintf("%-14s %v\n", "day of week", IntSliceToString(t.DaysOfWeek)))
-	sb.WriteString(fmt.Sprintf("%-14s %v\n", "command", t.Command))
+	daysOfWeek := DaysToString(t.DaysOfWeek, t.DaysOfWeekRules)
+	if t.DaysOfWeekExpr != "" {
+		daysOfWeek = fmt.Sprintf("%v (written as %v)", daysOfWeek, t.DaysOfWeekExpr)
+	}
+	sb.WriteString(fmt.Sprintf("%-14s %v\n", "day of week", daysOfWeek))
+	if t.Years != nil {
+		sb.WriteString(fmt.Sprintf("%-14s %v\n", "year", YearsToString(t.Years)))
+	}
+	if t.Location != nil {
+		sb.WriteString(fmt.Sprintf("%-14s %v\n", "timezone", t.Location))
+	}
+	if t.User != "" {
+		sb.WriteString(fmt.Sprintf("%-14s %v\n", "user", t.User))
+	}
+	// Schedules parsed on their own don't have a command
+	if t.Command != "" {
+		sb.WriteString(fmt.Sprintf("%-14s %v\n", "command", t.Command))
+	}
+	if t.Stdin != "" {
+		sb.WriteString(fmt.Sprintf("%-14s %q\n", "stdin", t.Stdin))
+	}
 	return sb.String()
 }
 
+type FieldKind byte
+
+const (
+	FieldSecond FieldKind = iota
+	FieldMinute
+	FieldHour
+	FieldDayOfMonth
+	FieldMonth
+	FieldDayOfWeek
+	FieldYear
+)
+
+func (k FieldKind) String() string {
+	switch k {
+	case FieldSecond:
+		return "second"
+	case FieldMinute:
+		return "minute"
+	case FieldHour:
+		return "hour"
+	case FieldDayOfMonth:
+		return "day of month"
+	case FieldMonth:
+		return "month"
+	case FieldDayOfWeek:
+		return "day of week"
+	case FieldYear:
+		return "year"
+	default:
+		return "unknown"
+	}
+}
+
+// cronField describes the values accepted by a single time field
+type cronField struct {
+	kind   FieldKind
+	minVal int
+	maxVal int
+	// names lists the aliases for the field's values in order, starting at minVal
+	names []string
+	// aliases maps values which are alternative spellings of another value
+	aliases map[int]int
+	// wrapAround allows ranges to run past maxVal back to minVal (22-2)
+	wrapAround bool
+	// hashSeed is the text the H operator derives the field's values from
+	hashSeed string
+	// shift is taken off the field's values once they're resolved, so that
+	// dialects numbering the days of the week from 1 store them from 0
+	shift int
+}
+
+var (
+	secondField     = cronField{kind: FieldSecond, minVal: 0, maxVal: 59}
+	minuteField     = cronField{kind: FieldMinute, minVal: 0, maxVal: 59}
+	hourField       = cronField{kind: FieldHour, minVal: 0, maxVal: 23}
+	dayOfMonthField = cronField{kind: FieldDayOfMonth, minVal: 1, maxVal: 31}
+	monthField      = cronField{kind: FieldMonth, minVal: 1, maxVal: 12, names: []string{
+		"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}}
+	// Sunday can be written as either 0 or 7
+	dayOfWeekField = cronField{kind: FieldDayOfWeek, minVal: 0, maxVal: 7, names: []string{
+		"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}, aliases: map[int]int{7: 0}}
+	yearField = cronField{kind: FieldYear, minVal: 1970, maxVal: 2099}
+)
+
+// cycleMax is the highest value of the field which isn't an alias of another
+func (f cronField) cycleMax() int {
+	maxVal := f.maxVal
+	for _, isAlias := f.aliases[maxVal]; isAlias; _, isAlias = f.aliases[maxVal] {
+		maxVal--
+	}
+	return maxVal
+}
+
+// hashValue picks a stable number between 0 and n-1 for the field based on
+// its hash seed, different fields pick different numbers for the same seed
+func (f cronField) hashValue(n int) int {
+	h := fnv.New32a()
+	h.Write([]byte(f.hashSeed))
+	h.Write([]byte{0})
+	h.Write([]byte(f.kind.String()))
+	return int(h.Sum32() % uint32(n))
+}
+
+// normalise replaces an alternative spelling of a value with the value itself
+func (f cronField) normalise(value int) int {
+	if alias, found := f.aliases[value]; found {
+		return alias
+	}
+	return value
+}
+
 func getTimeVal(fieldValues map[int]struct{}, timeValue int, minVal int, maxVal int) (success bool) {
 	if timeValue < minVal || timeValue > maxVal {
 		return false
@@ -35,26 +206,55 @@ func getTimeVal(fieldValues map[int]struct{}, timeValue int, minVal int, maxVal
 	return true
 }
 
-func listNodeTimeRange(node AstNode) (start int, end int, err error) {
+// getNodeValue resolves a number or a name node into a value of the given field
+func getNodeValue(node AstNode, field cronField) (int, error) {
+	switch node.NodeType {
+	case AstTimeVal:
+		value, err := strconv.Atoi(node.Value)
+		if err != nil {
+			return 0, fmt.Errorf("time value needs to be a valid number, got %v", node.Value)
+		}
+		return value, nil
+
+	case AstTimeName:
+		if len(field.names) == 0 {
+			return 0, fmt.Errorf("names are not supported in the %v field, got %v", field.kind, node.Value)
+		}
+		for i, name := range field.names {
+			if strings.EqualFold(name, node.Value) {
+				return field.minVal + i, nil
+			}
+		}
+		return 0, fmt.Errorf("%v is not a valid name for the %v field, expected one of %v",
+			node.Value, field.kind, strings.Join(field.names, " "))
+
+	default:
+		return 0, fmt.Errorf("expected a number or a name, got %v", node.NodeType)
+	}
+}
+
+func listNodeTimeRange(node AstNode, field cronField) (start int, end int, err error) {
 	if len(node.Children) != 2 {
 		err = fmt.Errorf("invalid time range format: %v", node)
 		return
 	}
-	if node.Children[0].NodeType != AstTimeVal || node.Children[1].NodeType != AstTimeVal {
-		err = fmt.Errorf("time range needs to consist of 2 integers, got %v and %v", node.Children[0].NodeType, node.Children[1].NodeType)
-		return
-	}
 
-	start, err = strconv.Atoi(node.Children[0].Value)
+	start, err = getNodeValue(node.Children[0], field)
 	if err != nil {
 		return
 	}
-	end, err = strconv.Atoi(node.Children[1].Value)
+	end, err = getNodeValue(node.Children[1], field)
 	if err != nil {
 		return
 	}
-	if start > end {
-		err = fmt.Errorf("time range needs to start from a lower to a higher value, got %v", node.Value)
+	if start > end && field.wrapAround {
+		// Sunday written as 7 doesn't need to wrap around to reach 0-2
+		start = field.normalise(start)
+	}
+	if start > end && !field.wrapAround {
+		err = fmt.Errorf("time range needs to start from a lower to a higher value, got %v "+
+			"(enable wrap-around ranges to run from %v past the end of the field to %v)",
+			node.Value, start, end)
 		return
 	}
 	return
@@ -66,15 +266,96 @@ func getTimeRange(fieldValues map[int]struct{}, start int, end int, steps int) {
 	}
 }
 
-func getExpressionPart(ast AstNode, fieldValues map[int]struct{}, minVal int, maxVal int) error {
+// getFieldRange adds the values of a range to the field, ranges which start
+// from a higher value wrap around past the end of the field
+func getFieldRange(fieldValues map[int]struct{}, field cronField, start int, end int, steps int) {
+	if start <= end {
+		getTimeRange(fieldValues, start, end, steps)
+		return
+	}
+
+	// Values which are aliases of lower values aren't part of the cycle
+	cycle := field.cycleMax() - field.minVal + 1
+	for i := start; i <= end+cycle; i += steps {
+		fieldValues[field.minVal+(i-field.minVal)%cycle] = struct{}{}
+	}
+}
+
+// getHashedValues adds the values picked by the H operator, the values are
+// spread over the range based on the field's hash seed
+func getHashedValues(ast AstNode, fieldValues map[int]struct{}, field cronField) error {
+	if len(ast.Children) == 0 || len(ast.Children) > 2 {
+		return fmt.Errorf("invalid hash format: %v", ast)
+	}
+	// Schedules without a command would all pick the same values
+	if field.hashSeed == "" {
+		return fmt.Errorf("H needs a seed when there's no command, set one with WithHashSeed (--seed)")
+	}
+
+	// Pick from the whole field by default, the day of month stops at the 28th
+	// so that the task runs in every month
+	start, end := field.minVal, field.cycleMax()
+	if field.kind == FieldDayOfMonth {
+		end = 28
+	}
+	cycle := field.cycleMax() - field.minVal + 1
+	if ast.Children[0].NodeType == AstTimeRange {
+		var err error
+		start, end, err = listNodeTimeRange(ast.Children[0], field)
+		if err != nil {
+			return err
+		}
+		if min(start, end) < field.minVal || max(start, end) > field.maxVal {
+			return fmt.Errorf("hash range needs to be between %v and %v, got %v and %v",
+				field.minVal, field.maxVal, start, end)
+		}
+		// A range over the whole cycle such as 0-7 in the day of week field
+		// would end on its own start once the alias is replaced
+		wholeCycle := start <= end && end-start+1 >= cycle
+		start, end = field.normalise(start), field.normalise(end)
+		if wholeCycle {
+			end = field.minVal + (start-1-field.minVal+cycle)%cycle
+		}
+	}
+
+	// List the range in order, wrapping around past the end of the field
+	rangeValues := []int{start}
+	for i := start; i != end; {
+		i = field.minVal + (i+1-field.minVal)%cycle
+		rangeValues = append(rangeValues, i)
+	}
+
+	// A single value anywhere in the range
+	if len(ast.Children) == 1 {
+		fieldValues[rangeValues[field.hashValue(len(rangeValues))]] = struct{}{}
+		return nil
+	}
+
+	// Every nth value of the range, starting from a hashed offset
+	steps, err := strconv.Atoi(ast.Children[1].Value)
+	if err != nil || steps < 1 {
+		return fmt.Errorf("hash steps value needs to be at least 1, got %v", ast.Children[1].Value)
+	}
+	for i := field.hashValue(min(steps, len(rangeValues))); i < len(rangeValues); i += steps {
+		fieldValues[rangeValues[i]] = struct{}{}
+	}
+	return nil
+}
+
+func getExpressionPart(ast AstNode, fieldValues map[int]struct{}, dayRules *[]DayRule, field cronField) error {
+	minVal, maxVal := field.minVal, field.maxVal
+
 	switch ast.NodeType {
 	case AstAsterisk:
+		if ast.Value == "?" && field.kind != FieldDayOfMonth && field.kind != FieldDayOfWeek {
+			return fmt.Errorf("? is only supported in the day of month and day of week fields, got it in the %v field", field.kind)
+		}
 		getTimeRange(fieldValues, minVal, maxVal, 1)
 
-	case AstTimeVal:
-		timeValue, err := strconv.Atoi(ast.Value)
+	case AstTimeVal, AstTimeName:
+		timeValue, err := getNodeValue(ast, field)
 		if err != nil {
-			return fmt.Errorf("steps value needs to be a valid number, got %v", ast.Children[1].Value)
+			return err
 		}
 		if timeValue < minVal || timeValue > maxVal {
 			return fmt.Errorf("time value needs to be between %v and %v, got %v", minVal, maxVal, timeValue)
@@ -82,14 +363,14 @@ func getExpressionPart(ast AstNode, fieldValues map[int]struct{}, minVal int, ma
 		getTimeVal(fieldValues, timeValue, minVal, maxVal)
 
 	case AstTimeRange:
-		start, end, err := listNodeTimeRange(ast)
+		start, end, err := listNodeTimeRange(ast, field)
 		if err != nil {
 			return err
 		}
-		if start > maxVal || end > maxVal {
+		if min(start, end) < minVal || max(start, end) > maxVal {
 			return fmt.Errorf("time range needs to be between %v and %v, got %v and %v", minVal, maxVal, start, end)
 		}
-		getTimeRange(fieldValues, max(minVal, start), min(maxVal, end), 1)
+		getFieldRange(fieldValues, field, start, end, 1)
 
 	case AstTimeSteps:
 		if len(ast.Children) != 2 {
@@ -103,47 +384,77 @@ func getExpressionPart(ast AstNode, fieldValues map[int]struct{}, minVal int, ma
 		if err != nil {
 			return fmt.Errorf("steps value needs to be a valid number, got %v", ast.Children[1].Value)
 		}
+		if steps < 1 {
+			return fmt.Errorf("steps value needs to be at least 1, got %v", steps)
+		}
 
 		switch ast.Children[0].NodeType {
 		// If it's steps for an asterisk (e.g. */5)
 		case AstAsterisk:
 			getTimeRange(fieldValues, minVal, maxVal, steps)
-		// If it's steps for a range (e.g. 1-10/5)
+		// If it's steps from a single value to the end of the field (e.g. 5/15)
+		case AstTimeVal, AstTimeName:
+			start, err := getNodeValue(ast.Children[0], field)
+			if err != nil {
+				return err
+			}
+			if start < minVal || start > maxVal {
+				return fmt.Errorf("steps start value needs to be between %v and %v, got %v", minVal, maxVal, start)
+			}
+			getTimeRange(fieldValues, start, maxVal, steps)
+		// If it's steps for a range (e.g. 1-10/5, MON-FRI/2 or 22-2/2)
 		case AstTimeRange:
-			start, end, err := listNodeTimeRange(ast.Children[0])
+			start, end, err := listNodeTimeRange(ast.Children[0], field)
 			if err != nil {
 				return err
 			}
-			if start > maxVal || end > maxVal {
+			if min(start, end) < minVal || max(start, end) > maxVal {
 				return fmt.Errorf("steps time range needs to be between %v and %v, got %v and %v", minVal, maxVal, start, end)
 			}
-			getTimeRange(fieldValues, max(minVal, start), min(maxVal, end), steps)
+			getFieldRange(fieldValues, field, start, end, steps)
 		default:
 			return fmt.Errorf("invalid time steps format: %v", fieldValues)
 		}
+
+	case AstHashed:
+		return getHashedValues(ast, fieldValues, field)
+
+	case AstLastDayOfMonth, AstNearestWeekday, AstLastWeekday, AstNthDayOfWeek, AstLastDayOfWeek:
+		// L on its own in the day of week field is the last day of the week,
+		// Saturday, as in Quartz and EventBridge
+		if isLastDayOfWeek(ast, field) {
+			getTimeVal(fieldValues, field.cycleMax(), minVal, maxVal)
+			return nil
+		}
+		rule, err := getDayRule(ast, field)
+		if err != nil {
+			return err
+		}
+		*dayRules = append(*dayRules, rule)
 	}
 
 	return nil
 }
 
-func getCronTimeField(ast AstNode, minVal int, maxVal int) ([]int, error) {
+func getCronTimeField(ast AstNode, field cronField) ([]int, []DayRule, error) {
 	// Expect a time field to consist of a single expression
 	if len(ast.Children) != 1 {
-		return nil, fmt.Errorf("invalid time expression format")
+		return nil, nil, fmt.Errorf("invalid time expression format")
 	}
 	if ast.Children[0].NodeType != AstTimeExpr {
-		return nil, fmt.Errorf("invalid time expression format")
+		return nil, nil, fmt.Errorf("invalid time expression format")
 	}
 
 	expression := ast.Children[0]
-	fieldRange := maxVal - minVal + 1
+	fieldRange := field.maxVal - field.minVal + 1
 	fieldValues := make(map[int]struct{}, 0)
+	var dayRules []DayRule
 
 	// get values for each part of the expression
 	for _, expr := range expression.Children {
-		err := getExpressionPart(expr, fieldValues, minVal, maxVal)
+		err := getExpressionPart(expr, fieldValues, &dayRules, field)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		// We're using every possible value for this field, we can return
 		if len(fieldValues) == fieldRange {
@@ -151,56 +462,274 @@ func getCronTimeField(ast AstNode, minVal int, maxVal int) ([]int, error) {
 		}
 	}
 
+	// replace alternative spellings, merging them with the values they stand for
+	for val := range fieldValues {
+		if alias := field.normalise(val); alias != val {
+			delete(fieldValues, val)
+			fieldValues[alias] = struct{}{}
+		}
+	}
+
 	// convert map keys to a slice
 	values := make([]int, len(fieldValues))
 	i := 0
 	for val := range fieldValues {
-		values[i] = val
+		values[i] = val - field.shift
 		i++
 	}
 	sort.Ints(values)
-	return values, nil
+	return values, dayRules, nil
 }
 
-func GetCronTask(ast *AstNode) (*CronTask, error) {
-	// Expect 6 children: 5 time fields and 1 command
-	if len(ast.Children) != 6 {
-		return nil, fmt.Errorf("invalid cron format, expected 5 time fields and a command")
+// setFieldValues stores the values of a time field in its place on the task
+func (t *CronTask) setFieldValues(kind FieldKind, values []int) {
+	switch kind {
+	case FieldSecond:
+		t.Seconds = values
+	case FieldMinute:
+		t.Minutes = values
+	case FieldHour:
+		t.Hours = values
+	case FieldDayOfMonth:
+		t.DaysOfMonth = values
+	case FieldMonth:
+		t.Months = values
+	case FieldDayOfWeek:
+		t.DaysOfWeek = values
+	case FieldYear:
+		t.Years = values
 	}
-	for i := 0; i < 5; i++ {
-		if ast.Children[i].NodeType != AstNodeField {
-			return nil, fmt.Errorf("expected 5 time fields followed by a command")
-		}
+}
+
+// isUnrestricted checks whether a time field starts with an asterisk (* or */2),
+// following vixie cron which treats such fields as unrestricted
+func isUnrestricted(ast AstNode) bool {
+	if len(ast.Children) != 1 || len(ast.Children[0].Children) == 0 {
+		return false
 	}
-	if ast.Children[5].NodeType != AstNodeCommand {
-		return nil, fmt.Errorf("expected after 5 time fields")
+	firstPart := ast.Children[0].Children[0]
+	switch firstPart.NodeType {
+	case AstAsterisk:
+		return true
+	case AstTimeSteps:
+		return len(firstPart.Children) > 0 && firstPart.Children[0].NodeType == AstAsterisk
+	default:
+		return false
 	}
+}
 
-	task := CronTask{}
+// isLastDayOfWeek checks for an L on its own in the day of week field
+func isLastDayOfWeek(node AstNode, field cronField) bool {
+	return node.NodeType == AstLastDayOfMonth && len(node.Children) == 0 && field.kind == FieldDayOfWeek
+}
 
-	// Get trigger times from time fields
-	var err error
-	task.Minutes, err = getCronTimeField(ast.Children[0], 0, 59)
-	if err != nil {
-		return nil, err
+// writtenWithAlias checks whether the values of a time expression use an
+// alternative spelling, such as 7 for Sunday or L for Saturday. Step sizes aren't values and day
+// rules keep their own spelling
+func writtenWithAlias(node AstNode, field cronField) bool {
+	switch node.NodeType {
+	case AstTimeVal, AstTimeName:
+		value, err := getNodeValue(node, field)
+		return err == nil && field.normalise(value) != value
+	case AstLastDayOfMonth:
+		return isLastDayOfWeek(node, field)
+	case AstTimeSteps, AstHashed:
+		return len(node.Children) > 0 && writtenWithAlias(node.Children[0], field)
+	case AstTimeExpr, AstTimeRange:
+		for _, child := range node.Children {
+			if writtenWithAlias(child, field) {
+				return true
+			}
+		}
 	}
-	task.Hours, err = getCronTimeField(ast.Children[1], 0, 23)
-	if err != nil {
-		return nil, err
+	return false
+}
+
+// isQuestionMark checks whether a time field is just a question mark
+func isQuestionMark(ast AstNode) bool {
+	return len(ast.Children) == 1 && len(ast.Children[0].Children) == 1 &&
+		ast.Children[0].Children[0].NodeType == AstAsterisk && ast.Children[0].Children[0].Value == "?"
+}
+
+// getTimezone looks up a time zone in the system's time zone database
+func getTimezone(ast AstNode) (*time.Location, error) {
+	if ast.Value == "" {
+		return nil, fmt.Errorf("time zone assignment needs a time zone name, e.g. CRON_TZ=Europe/London")
 	}
-	task.DaysOfMonth, err = getCronTimeField(ast.Children[2], 1, 31)
+	location, err := time.LoadLocation(ast.Value)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("couldn't load the time zone: %v", err)
 	}
-	task.Months, err = getCronTimeField(ast.Children[3], 1, 12)
-	if err != nil {
-		return nil, err
+	return location, nil
+}
+
+// splitCommand separates the command from the text sent to its standard
+// input as vixie cron does: the first unescaped % ends the command and every
+// further one is a newline, \% is a literal %
+func splitCommand(raw string) (command string, stdin string) {
+	var sb strings.Builder
+	inStdin := false
+	escaped := false
+	for _, char := range raw {
+		switch {
+		case escaped:
+			if char != '%' {
+				sb.WriteRune('\\')
+			}
+			sb.WriteRune(char)
+			escaped = false
+		case char == '\\':
+			escaped = true
+		case char == '%' && !inStdin:
+			command = sb.String()
+			sb.Reset()
+			inStdin = true
+		case char == '%':
+			sb.WriteRune('\n')
+		default:
+			sb.WriteRune(char)
+		}
+	}
+	if escaped {
+		sb.WriteRune('\\')
+	}
+	if !inStdin {
+		return sb.String(), ""
 	}
-	task.DaysOfWeek, err = getCronTimeField(ast.Children[4], 0, 6)
+	return command, sb.String()
+}
+
+// minInterval is the shortest interval of an @every schedule, intervals are
+// counted in whole multiples of it
+const minInterval = time.Second
+
+// getInterval reads the interval of an @every schedule as time.ParseDuration does
+func getInterval(ast AstNode) (time.Duration, error) {
+	interval, err := time.ParseDuration(ast.Value)
 	if err != nil {
-		return nil, err
+		return 0, fmt.Errorf("invalid @every interval: %v", err)
+	}
+	if interval < minInterval {
+		return 0, fmt.Errorf("@every interval needs to be at least %v, got %v", minInterval, ast.Value)
+	}
+	if interval%minInterval != 0 {
+		return 0, fmt.Errorf("@every interval needs to be a whole number of seconds, got %v", ast.Value)
+	}
+	return interval, nil
+}
+
+// userNamePattern matches the user names accepted by useradd and most
+// password databases, machine accounts may end with a $
+var userNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*\$?$`)
+
+// getUser checks the user column of a system crontab holds a plausible user name
+func getUser(ast AstNode) (string, error) {
+	if len(ast.Value) > 32 || !userNamePattern.MatchString(ast.Value) {
+		return "", fmt.Errorf("%v is not a valid user name, expected up to 32 letters, digits, "+
+			"'_', '-' or '.' starting with a letter or '_'", ast.Value)
+	}
+	return ast.Value, nil
+}
+
+func GetCronTask(ast *AstNode, opts ...CompileOption) (*CronTask, error) {
+	options := getCompileOptions(opts)
+	task := CronTask{}
+	fields := ast.Children
+	if len(fields) > 0 && fields[len(fields)-1].NodeType == AstNodeCommand {
+		task.Command, task.Stdin = splitCommand(fields[len(fields)-1].Value)
+		fields = fields[:len(fields)-1]
+	} else if !options.ScheduleOnly {
+		return nil, fmt.Errorf("invalid cron format, expected the time fields to be followed by a command")
+	}
+
+	// Load the time zone the schedule is in
+	if len(fields) > 0 && fields[0].NodeType == AstTimezone {
+		location, err := getTimezone(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		task.Location = location
+		fields = fields[1:]
+	}
+
+	// The user column of a system crontab sits between the schedule and the
+	// command
+	if options.SystemCrontab {
+		if len(fields) == 0 || fields[len(fields)-1].NodeType != AstUser {
+			return nil, fmt.Errorf("invalid system crontab format, expected the time fields to be followed by a user and a command")
+		}
+		user, err := getUser(fields[len(fields)-1])
+		if err != nil {
+			return nil, err
+		}
+		task.User = user
+		fields = fields[:len(fields)-1]
+	}
+
+	// Predefined schedules carry the time fields they expand to
+	if len(fields) == 1 && fields[0].NodeType == AstMacro {
+		if len(fields[0].Children) == 1 && fields[0].Children[0].NodeType == AstDuration {
+			interval, err := getInterval(fields[0].Children[0])
+			if err != nil {
+				return nil, err
+			}
+			task.Interval = interval
+			return &task, nil
+		}
+		if len(fields[0].Children) == 0 {
+			task.Event = strings.TrimPrefix(fields[0].Value, "@")
+			return &task, nil
+		}
+		fields = fields[0].Children
+	}
+
+	// Expect a node for each of the time fields
+	timeFields := options.timeFields()
+	if len(fields) != len(timeFields) {
+		return nil, fmt.Errorf("invalid cron format, expected %v time fields and a command", len(timeFields))
+	}
+	for _, field := range fields {
+		if field.NodeType != AstNodeField {
+			return nil, fmt.Errorf("expected %v time fields followed by a command", len(timeFields))
+		}
+	}
+
+	// The H operator spreads tasks based on their command unless told otherwise
+	hashSeed := options.HashSeed
+	if hashSeed == "" {
+		hashSeed = task.Command
+	}
+
+	// Get trigger times from time fields
+	questionMarks := 0
+	for i, timeField := range timeFields {
+		timeField.hashSeed = hashSeed
+		values, dayRules, err := getCronTimeField(fields[i], timeField)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %v field: %v", timeField.kind, err)
+		}
+		task.setFieldValues(timeField.kind, values)
+		switch timeField.kind {
+		case FieldDayOfMonth:
+			task.DaysOfMonthRules = dayRules
+			task.DaysOfMonthUnrestricted = isUnrestricted(fields[i])
+		case FieldDayOfWeek:
+			task.DaysOfWeekRules = dayRules
+			task.DaysOfWeekUnrestricted = isUnrestricted(fields[i])
+			if writtenWithAlias(fields[i].Children[0], timeField) {
+				task.DaysOfWeekExpr = fields[i].Value
+			}
+		}
+		if isQuestionMark(fields[i]) {
+			questionMarks++
+		}
+	}
+
+	// Some dialects pick the days with only one of the day fields
+	if options.Dialect.ExclusiveDays && questionMarks != 1 {
+		return nil, fmt.Errorf("the %v dialect needs ? in exactly one of the day of month and day of week fields",
+			options.Dialect.Name)
 	}
 
-	task.Command = ast.Children[5].Value
 	return &task, nil
 }
@@ -2,7 +2,9 @@ package main
 
 import (
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestGetCronTask(t *testing.T) {
@@ -183,3 +185,144 @@ func TestGetCronTask(t *testing.T) {
 		}
 	}
 }
+
+func TestCronTaskString(t *testing.T) {
+	tests := []struct {
+		inputTask CronTask
+		expected  string
+	}{
+		{
+			CronTask{
+				Minutes:     []int{0, 30},
+				Hours:       []int{1},
+				DaysOfMonth: []int{1, 15},
+				Months:      []int{6},
+				DaysOfWeek:  []int{1, 2},
+				Command:     "/usr/bin/find",
+			},
+			"minute         0 30\n" +
+				"hour           1\n" +
+				"day of month   1 15\n" +
+				"month          6\n" +
+				"day of week    1 2\n" +
+				"command        /usr/bin/find\n",
+		},
+		{
+			CronTask{
+				Seconds:     []int{0},
+				Minutes:     []int{5},
+				Hours:       []int{1},
+				DaysOfMonth: []int{1},
+				Months:      []int{6},
+				DaysOfWeek:  []int{1},
+				Command:     "/usr/bin/find",
+			},
+			"second         0\n" +
+				"minute         5\n" +
+				"hour           1\n" +
+				"day of month   1\n" +
+				"month          6\n" +
+				"day of week    1\n" +
+				"command        /usr/bin/find\n",
+		},
+		{
+			CronTask{
+				Minutes:     []int{0},
+				Hours:       []int{9},
+				DaysOfMonth: []int{1},
+				Months:      []int{6},
+				DaysOfWeek:  []int{1},
+				Location:    time.UTC,
+				Command:     "/usr/bin/report",
+			},
+			"minute         0\n" +
+				"hour           9\n" +
+				"day of month   1\n" +
+				"month          6\n" +
+				"day of week    1\n" +
+				"timezone       UTC\n" +
+				"command        /usr/bin/report\n",
+		},
+		{
+			CronTask{Event: "reboot", Command: "/usr/bin/startup"},
+			"event          reboot\n" +
+				"command        /usr/bin/startup\n",
+		},
+		{
+			CronTask{Event: "reboot", Command: "mail root", Stdin: "hello\nworld"},
+			"event          reboot\n" +
+				"command        mail root\n" +
+				"stdin          \"hello\\nworld\"\n",
+		},
+		{
+			CronTask{Interval: 5 * time.Minute},
+			"interval       5m0s\n",
+		},
+		{
+			CronTask{Event: "reboot", User: "root", Command: "/usr/bin/startup"},
+			"event          reboot\n" +
+				"user           root\n" +
+				"command        /usr/bin/startup\n",
+		},
+	}
+
+	for i, test := range tests {
+		res := test.inputTask.String()
+
+		if res != test.expected {
+			t.Errorf("test %v, expected %q, got %q", i, test.expected, res)
+		}
+	}
+}
+
+func TestCronTaskStringSpelling(t *testing.T) {
+	tests := []struct {
+		inputCronStr string
+		expected     string
+	}{
+		// Sunday written as 7 is stored as 0 but shown as written
+		{"0 0 * * 5-7 /usr/bin/weekend", "day of week    0 5 6 (written as 5-7)\n"},
+		{"0 0 * * 7L /usr/bin/weekend", "day of week    7L\n"},
+		{"0 0 * * 1,7L /usr/bin/weekend", "day of week    1 7L\n"},
+		{"0 0 * * FRI#2 /usr/bin/weekend", "day of week    FRI#2\n"},
+		// Step sizes aren't days of the week
+		{"0 0 * * */7 /usr/bin/weekend", "day of week    0\n"},
+	}
+
+	for _, test := range tests {
+		task, err := CronTaskCompile(test.inputCronStr)
+		if err != nil {
+			t.Errorf("test %v, expected no error, got %v", test.inputCronStr, err)
+			continue
+		}
+		res := task.String()
+
+		if !strings.Contains(res, test.expected) {
+			t.Errorf("test %v, expected %q in %q", test.inputCronStr, test.expected, res)
+		}
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedCommand string
+		expectedStdin   string
+	}{
+		{"/usr/bin/find", "/usr/bin/find", ""},
+		{"date +\\%Y-\\%m-\\%d", "date +%Y-%m-%d", ""},
+		{"mail root%hello%world", "mail root", "hello\nworld"},
+		{"cat%100\\% done%", "cat", "100% done\n"},
+		{"echo \\$HOME \\\\%x", "echo \\$HOME \\\\", "x"},
+		{"cat%", "cat", ""},
+		{"echo \\", "echo \\", ""},
+	}
+
+	for _, test := range tests {
+		command, stdin := splitCommand(test.input)
+		if command != test.expectedCommand || stdin != test.expectedStdin {
+			t.Errorf("test %v, expected %q and %q, got %q and %q",
+				test.input, test.expectedCommand, test.expectedStdin, command, stdin)
+		}
+	}
+}
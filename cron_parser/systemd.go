@@ -0,0 +1,418 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// systemdShorthands maps the named calendar events of systemd.time(7) to the
+// full expressions they stand for
+var systemdShorthands = map[string]string{
+	"minutely":     "*-*-* *:*:00",
+	"hourly":       "*-*-* *:00:00",
+	"daily":        "*-*-* 00:00:00",
+	"monthly":      "*-*-01 00:00:00",
+	"weekly":       "Mon *-*-* 00:00:00",
+	"yearly":       "*-01-01 00:00:00",
+	"annually":     "*-01-01 00:00:00",
+	"quarterly":    "*-01,04,07,10-01 00:00:00",
+	"semiannually": "*-01,07-01 00:00:00",
+}
+
+// systemdWeekdays lists the names of the days of the week from Sunday, as
+// written in calendar events
+var systemdWeekdays = []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+
+// getSystemdWeekday resolves an abbreviated or a full English day name
+func getSystemdWeekday(name string) (int, error) {
+	for i, weekday := range systemdWeekdays {
+		if strings.EqualFold(name, weekday) || strings.EqualFold(name, time.Weekday(i).String()) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("%v is not a valid day of the week, expected one of %v",
+		name, strings.Join(systemdWeekdays, " "))
+}
+
+// isSystemdWeekdays checks whether a part of a calendar event lists days of
+// the week, e.g. Mon..Fri or Sat,Sun
+func isSystemdWeekdays(part string) bool {
+	for _, item := range strings.Split(part, ",") {
+		for _, name := range strings.Split(item, "..") {
+			if _, err := getSystemdWeekday(name); err != nil {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// parseSystemdWeekdays lists the days of the week of a calendar event
+func parseSystemdWeekdays(part string) ([]int, error) {
+	days := map[int]struct{}{}
+	for _, item := range strings.Split(part, ",") {
+		names := strings.Split(item, "..")
+		if len(names) > 2 {
+			return nil, fmt.Errorf("invalid day of week range %v", item)
+		}
+		start, err := getSystemdWeekday(names[0])
+		if err != nil {
+			return nil, err
+		}
+		end := start
+		if len(names) == 2 {
+			if end, err = getSystemdWeekday(names[1]); err != nil {
+				return nil, err
+			}
+		}
+		// Ranges run from Monday to Sunday
+		if (start+6)%7 > (end+6)%7 {
+			return nil, fmt.Errorf("day of week range needs to run from an earlier to a later day, got %v", item)
+		}
+		for day := start; day != (end+1)%7; day = (day + 1) % 7 {
+			days[day] = struct{}{}
+		}
+	}
+	return sortedValues(days), nil
+}
+
+// sortedValues lists the values of a set in order
+func sortedValues(set map[int]struct{}) []int {
+	values := []int{}
+	for value := range set {
+		values = append(values, value)
+	}
+	sort.Ints(values)
+	return values
+}
+
+// parseSystemdComponent lists the values of a single component of a calendar
+// event, a list of values, a..b ranges and /n repetitions or *
+func parseSystemdComponent(component string, field cronField) ([]int, error) {
+	values := map[int]struct{}{}
+	for _, item := range strings.Split(component, ",") {
+		base, step := item, 0
+		if i := strings.Index(item, "/"); i >= 0 {
+			var err error
+			base = item[:i]
+			step, err = strconv.Atoi(item[i+1:])
+			if err != nil || step < 1 {
+				return nil, fmt.Errorf("%v repetition needs to be at least 1, got %v", field.kind, item)
+			}
+		}
+
+		start, end := field.minVal, field.maxVal
+		switch {
+		case base == "*":
+		case strings.Contains(base, ".."):
+			bounds := strings.Split(base, "..")
+			if len(bounds) != 2 {
+				return nil, fmt.Errorf("invalid %v range %v", field.kind, item)
+			}
+			var err error
+			if start, err = getSystemdValue(bounds[0], field); err != nil {
+				return nil, err
+			}
+			if end, err = getSystemdValue(bounds[1], field); err != nil {
+				return nil, err
+			}
+			if start > end {
+				return nil, fmt.Errorf("%v range needs to start from a lower to a higher value, got %v", field.kind, item)
+			}
+		default:
+			var err error
+			if start, err = getSystemdValue(base, field); err != nil {
+				return nil, err
+			}
+			// A single value repeats until the end of the field
+			if step == 0 {
+				end = start
+			}
+		}
+		if step == 0 {
+			step = 1
+		}
+		for value := start; value <= end; value += step {
+			values[value] = struct{}{}
+		}
+	}
+	return sortedValues(values), nil
+}
+
+// getSystemdValue reads a number of a calendar event component
+func getSystemdValue(text string, field cronField) (int, error) {
+	value, err := strconv.Atoi(text)
+	if err != nil {
+		if strings.Contains(text, ".") && field.kind == FieldSecond {
+			return 0, fmt.Errorf("fractional seconds are not supported, got %v", text)
+		}
+		return 0, fmt.Errorf("%v value needs to be a valid number, got %v", field.kind, text)
+	}
+	if value < field.minVal || value > field.maxVal {
+		return 0, fmt.Errorf("%v value needs to be between %v and %v, got %v", field.kind, field.minVal, field.maxVal, value)
+	}
+	return value, nil
+}
+
+// parseSystemdDate reads the [year-]month-day part of a calendar event onto
+// the task, days after ~ count back from the end of the month
+func parseSystemdDate(part string, task *CronTask) error {
+	date, lastDays, fromEnd := strings.Cut(part, "~")
+	components := strings.Split(date, "-")
+	if fromEnd {
+		components = append(components, lastDays)
+	}
+	if len(components) == 2 {
+		components = append([]string{"*"}, components...)
+	}
+	if len(components) != 3 {
+		return fmt.Errorf("date needs to be written as year-month-day or month-day, got %v", part)
+	}
+
+	var err error
+	if components[0] != "*" {
+		if task.Years, err = parseSystemdComponent(components[0], yearField); err != nil {
+			return err
+		}
+	}
+	if task.Months, err = parseSystemdComponent(components[1], monthField); err != nil {
+		return err
+	}
+	if !fromEnd {
+		task.DaysOfMonth, err = parseSystemdComponent(components[2], dayOfMonthField)
+		task.DaysOfMonthUnrestricted = components[2] == "*"
+		return err
+	}
+
+	// ~1 is the last day of the month, ~2 the day before it
+	days, err := parseSystemdComponent(components[2], dayOfMonthField)
+	if err != nil {
+		return err
+	}
+	task.DaysOfMonth = []int{}
+	task.DaysOfMonthUnrestricted = false
+	for _, day := range days {
+		task.DaysOfMonthRules = append(task.DaysOfMonthRules, DayRule{Kind: DayRuleLastDayOfMonth, Offset: day - 1})
+	}
+	return nil
+}
+
+// parseSystemdTime reads the hour:minute[:second] part of a calendar event
+// onto the task
+func parseSystemdTime(part string, task *CronTask) error {
+	components := strings.Split(part, ":")
+	if len(components) == 2 {
+		components = append(components, "00")
+	}
+	if len(components) != 3 {
+		return fmt.Errorf("time needs to be written as hour:minute[:second], got %v", part)
+	}
+
+	var err error
+	if task.Hours, err = parseSystemdComponent(components[0], hourField); err != nil {
+		return err
+	}
+	if task.Minutes, err = parseSystemdComponent(components[1], minuteField); err != nil {
+		return err
+	}
+	task.Seconds, err = parseSystemdComponent(components[2], secondField)
+	return err
+}
+
+// ParseOnCalendar compiles a systemd calendar event expression, the value of
+// an OnCalendar= setting, e.g. "Mon..Fri *-*-* 09:00:00" or "weekly". Both
+// the days of the week and the date have to match, a missing date matches
+// every day and a missing time is midnight
+func ParseOnCalendar(expression string) (*CronTask, error) {
+	expression = strings.TrimSpace(expression)
+	if full, found := systemdShorthands[strings.ToLower(expression)]; found {
+		expression = full
+	}
+	parts := strings.Fields(expression)
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("calendar event needs at least a day of the week, a date or a time")
+	}
+
+	task := &CronTask{MatchBothDays: true}
+	var err error
+
+	// The days of the week come first
+	task.DaysOfWeek = []int{0, 1, 2, 3, 4, 5, 6}
+	task.DaysOfWeekUnrestricted = true
+	if isSystemdWeekdays(parts[0]) || strings.Contains(parts[0], "..") && !strings.ContainsAny(parts[0], "-:~") {
+		if task.DaysOfWeek, err = parseSystemdWeekdays(parts[0]); err != nil {
+			return nil, err
+		}
+		task.DaysOfWeekUnrestricted = false
+		parts = parts[1:]
+	}
+
+	// Followed by the date, then the time
+	if err = parseSystemdDate("*-*-*", task); err != nil {
+		return nil, err
+	}
+	if len(parts) > 0 && strings.ContainsAny(parts[0], "-~") && !strings.Contains(parts[0], ":") {
+		if err = parseSystemdDate(parts[0], task); err != nil {
+			return nil, err
+		}
+		parts = parts[1:]
+	}
+	if err = parseSystemdTime("00:00:00", task); err != nil {
+		return nil, err
+	}
+	if len(parts) > 0 && strings.Contains(parts[0], ":") {
+		if err = parseSystemdTime(parts[0], task); err != nil {
+			return nil, err
+		}
+		parts = parts[1:]
+	}
+
+	// And optionally the time zone
+	if len(parts) == 1 {
+		if task.Location, err = time.LoadLocation(parts[0]); err != nil {
+			return nil, fmt.Errorf("%v is not a valid date, time or time zone: %v", parts[0], err)
+		}
+		parts = parts[1:]
+	}
+	if len(parts) > 0 {
+		return nil, fmt.Errorf("unexpected %v, calendar events are written as "+
+			"[weekdays] [year-month-day] [hour:minute:second] [time zone]", strings.Join(parts, " "))
+	}
+	return task, nil
+}
+
+// systemdComponentString writes the values of a field in calendar event
+// syntax, using * for every value and a/n for repetitions running to the end
+// of the field
+func systemdComponentString(values []int, field cronField, width int) string {
+	format := func(value int) string {
+		return fmt.Sprintf("%0*d", width, value)
+	}
+	if len(values) == field.maxVal-field.minVal+1 {
+		return "*"
+	}
+
+	// A repetition from the first value to the end of the field
+	if len(values) > 2 {
+		step := values[1] - values[0]
+		repeats := step > 1 && values[len(values)-1]+step > field.maxVal
+		for i := 1; repeats && i < len(values); i++ {
+			repeats = values[i]-values[i-1] == step
+		}
+		if repeats {
+			return fmt.Sprintf("%v/%v", format(values[0]), step)
+		}
+	}
+
+	// Otherwise runs of consecutive values become ranges
+	parts := []string{}
+	for i := 0; i < len(values); {
+		j := i
+		for j+1 < len(values) && values[j+1] == values[j]+1 {
+			j++
+		}
+		if j-i >= 2 {
+			parts = append(parts, format(values[i])+".."+format(values[j]))
+		} else {
+			for k := i; k <= j; k++ {
+				parts = append(parts, format(values[k]))
+			}
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
+
+// systemdWeekdaysString writes days of the week in calendar event syntax,
+// weeks run from Monday
+func systemdWeekdaysString(days []int) string {
+	mondayFirst := make([]int, len(days))
+	for i, day := range days {
+		mondayFirst[i] = (day + 6) % 7
+	}
+	sort.Ints(mondayFirst)
+
+	parts := []string{}
+	for i := 0; i < len(mondayFirst); {
+		j := i
+		for j+1 < len(mondayFirst) && mondayFirst[j+1] == mondayFirst[j]+1 {
+			j++
+		}
+		first, last := systemdWeekdays[(mondayFirst[i]+1)%7], systemdWeekdays[(mondayFirst[j]+1)%7]
+		switch {
+		case i == j:
+			parts = append(parts, first)
+		case j == i+1:
+			parts = append(parts, first, last)
+		default:
+			parts = append(parts, first+".."+last)
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
+
+// ToOnCalendar converts a task into a systemd calendar event expression for an
+// OnCalendar= setting. Schedules systemd can't express, such as running on days
+// matching either of the day fields or on the nearest weekday, are an error
+func (t CronTask) ToOnCalendar() (string, error) {
+	if t.Event != "" {
+		return "", fmt.Errorf("@%v tasks don't run on a schedule, systemd triggers them with a unit dependency instead", t.Event)
+	}
+	if t.Interval != 0 {
+		return "", fmt.Errorf("tasks repeating every %v can't be written as a calendar event, use OnUnitActiveSec= instead", t.Interval)
+	}
+
+	allDaysOfMonth := len(t.DaysOfMonth) == dayOfMonthField.maxVal && len(t.DaysOfMonthRules) == 0
+	allDaysOfWeek := len(t.DaysOfWeek) == 7 && len(t.DaysOfWeekRules) == 0
+	matchBoth := t.MatchBothDays || t.DaysOfMonthUnrestricted || t.DaysOfWeekUnrestricted
+	if !allDaysOfMonth && !allDaysOfWeek && !matchBoth {
+		return "", fmt.Errorf("systemd can't run on days matching either the day of month or the day of week, " +
+			"it needs both of them to match")
+	}
+	if len(t.DaysOfWeekRules) > 0 {
+		return "", fmt.Errorf("systemd can't express the day of week rule %v", t.DaysOfWeekRules[0])
+	}
+
+	// The day is either a list of days or a count back from the end of the month
+	day := systemdComponentString(t.DaysOfMonth, dayOfMonthField, 2)
+	daySeparator := "-"
+	if len(t.DaysOfMonthRules) > 0 {
+		if len(t.DaysOfMonth) > 0 {
+			return "", fmt.Errorf("systemd can't mix days of the month with days counted back from the end of the month")
+		}
+		lastDays := []int{}
+		for _, rule := range t.DaysOfMonthRules {
+			if rule.Kind != DayRuleLastDayOfMonth {
+				return "", fmt.Errorf("systemd can't express the day of month rule %v", rule)
+			}
+			lastDays = append(lastDays, rule.Offset+1)
+		}
+		sort.Ints(lastDays)
+		day = systemdComponentString(lastDays, dayOfMonthField, 2)
+		daySeparator = "~"
+	}
+
+	year := "*"
+	if t.Years != nil {
+		year = systemdComponentString(t.Years, yearField, 4)
+	}
+	seconds := "00"
+	if t.Seconds != nil {
+		seconds = systemdComponentString(t.Seconds, secondField, 2)
+	}
+
+	var sb strings.Builder
+	if !allDaysOfWeek {
+		sb.WriteString(systemdWeekdaysString(t.DaysOfWeek) + " ")
+	}
+	sb.WriteString(fmt.Sprintf("%v-%v%v%v %v:%v:%v", year, systemdComponentString(t.Months, monthField, 2), daySeparator, day,
+		systemdComponentString(t.Hours, hourField, 2), systemdComponentString(t.Minutes, minuteField, 2), seconds))
+	if t.Location != nil {
+		sb.WriteString(" " + t.Location.String())
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestParseOnCalendar(t *testing.T) {
+	allSeconds := []int{0}
+	allHours := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23}
+	allDaysOfMonth := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31}
+	allMonths := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+	allDaysOfWeek := []int{0, 1, 2, 3, 4, 5, 6}
+
+	tests := []struct {
+		input         string
+		expectedTask  CronTask
+		expectedError error
+	}{
+		{
+			"Mon..Fri *-*-* 09:00:00",
+			CronTask{
+				Seconds:                 allSeconds,
+				Minutes:                 []int{0},
+				Hours:                   []int{9},
+				DaysOfMonth:             allDaysOfMonth,
+				Months:                  allMonths,
+				DaysOfWeek:              []int{1, 2, 3, 4, 5},
+				DaysOfMonthUnrestricted: true,
+				MatchBothDays:           true,
+			},
+			nil,
+		},
+		{
+			"weekly",
+			CronTask{
+				Seconds:                 allSeconds,
+				Minutes:                 []int{0},
+				Hours:                   []int{0},
+				DaysOfMonth:             allDaysOfMonth,
+				Months:                  allMonths,
+				DaysOfWeek:              []int{1},
+				DaysOfMonthUnrestricted: true,
+				MatchBothDays:           true,
+			},
+			nil,
+		},
+		{
+			"*:0/15",
+			CronTask{
+				Seconds:                 allSeconds,
+				Minutes:                 []int{0, 15, 30, 45},
+				Hours:                   allHours,
+				DaysOfMonth:             allDaysOfMonth,
+				Months:                  allMonths,
+				DaysOfWeek:              allDaysOfWeek,
+				DaysOfMonthUnrestricted: true,
+				DaysOfWeekUnrestricted:  true,
+				MatchBothDays:           true,
+			},
+			nil,
+		},
+		{
+			"Sat,Sunday 2027-01,07~02 10:30:15",
+			CronTask{
+				Seconds:          []int{15},
+				Minutes:          []int{30},
+				Hours:            []int{10},
+				DaysOfMonth:      []int{},
+				DaysOfMonthRules: []DayRule{{Kind: DayRuleLastDayOfMonth, Offset: 1}},
+				Months:           []int{1, 7},
+				DaysOfWeek:       []int{0, 6},
+				Years:            []int{2027},
+				MatchBothDays:    true,
+			},
+			nil,
+		},
+		{
+			"06-01..07 UTC",
+			CronTask{
+				Seconds:                allSeconds,
+				Minutes:                []int{0},
+				Hours:                  []int{0},
+				DaysOfMonth:            []int{1, 2, 3, 4, 5, 6, 7},
+				Months:                 []int{6},
+				DaysOfWeek:             allDaysOfWeek,
+				DaysOfWeekUnrestricted: true,
+				MatchBothDays:          true,
+				Location:               mustLoadLocation("UTC"),
+			},
+			nil,
+		},
+		{"", CronTask{}, fmt.Errorf("calendar event needs at least a day of the week, a date or a time")},
+		{"Fri..Mon", CronTask{}, fmt.Errorf("day of week range needs to run from an earlier to a later day, got Fri..Mon")},
+		{"Mon..Fru", CronTask{}, fmt.Errorf("Fru is not a valid day of the week, expected one of Sun Mon Tue Wed Thu Fri Sat")},
+		{"*-13-01", CronTask{}, fmt.Errorf("month value needs to be between 1 and 12, got 13")},
+		{"1-2-3-4", CronTask{}, fmt.Errorf("date needs to be written as year-month-day or month-day, got 1-2-3-4")},
+		{"25:00", CronTask{}, fmt.Errorf("hour value needs to be between 0 and 23, got 25")},
+		{"*:*:00.5", CronTask{}, fmt.Errorf("fractional seconds are not supported, got 00.5")},
+		{"*:0/0", CronTask{}, fmt.Errorf("minute repetition needs to be at least 1, got 0/0")},
+		{"Mars", CronTask{}, fmt.Errorf("Mars is not a valid date, time or time zone: unknown time zone Mars")},
+		{"*-*-* 00:00 UTC later", CronTask{},
+			fmt.Errorf("unexpected UTC later, calendar events are written as [weekdays] [year-month-day] [hour:minute:second] [time zone]")},
+	}
+
+	for _, test := range tests {
+		task, err := ParseOnCalendar(test.input)
+		if fmt.Sprint(err) != fmt.Sprint(test.expectedError) {
+			t.Errorf("test %v, expected error \"%v\", got \"%v\"", test.input, test.expectedError, err)
+		}
+		if err != nil {
+			continue
+		}
+		if !reflect.DeepEqual(*task, test.expectedTask) {
+			t.Errorf("test %v, expected %v, got %v", test.input, test.expectedTask, *task)
+		}
+	}
+}
+
+func TestToOnCalendar(t *testing.T) {
+	tests := []struct {
+		inputCronStr  string
+		inputOpts     []CompileOption
+		expected      string
+		expectedError error
+	}{
+		{"*/15 9-17 * * 1-5 /bin/job", nil, "Mon..Fri *-*-* 09..17:00/15:00", nil},
+		{"0 0 1 1 * /bin/job", nil, "*-01-01 00:00:00", nil},
+		{"5,10,20 * * * * /bin/job", nil, "*-*-* *:05,10,20:00", nil},
+		{"0 0 */2 * 5 /bin/job", nil, "Fri *-*-01/2 00:00:00", nil},
+		{"0 0 L-2 * * /bin/job", nil, "*-*~03 00:00:00", nil},
+		{"30 6 * 1,2,3,7 0,6 /bin/job", nil, "Sat,Sun *-01..03,07-* 06:30:00", nil},
+		{"0 0 * * 0-6 /bin/job", nil, "*-*-* 00:00:00", nil},
+		{"CRON_TZ=Europe/London 0 9 * * * /bin/job", nil, "*-*-* 09:00:00 Europe/London", nil},
+		{"*/10 0 0 * * * 2027-2029 /bin/job", []CompileOption{WithSeconds(), WithYear()}, "2027..2029-*-* 00:00:00/10", nil},
+		{"0 0 1,15 * 5 /bin/job", nil, "",
+			fmt.Errorf("systemd can't run on days matching either the day of month or the day of week, it needs both of them to match")},
+		{"0 0 15W * * /bin/job", nil, "", fmt.Errorf("systemd can't express the day of month rule 15W")},
+		{"0 0 * * 5L /bin/job", nil, "", fmt.Errorf("systemd can't express the day of week rule 5L")},
+		{"0 0 1,L * * /bin/job", nil, "",
+			fmt.Errorf("systemd can't mix days of the month with days counted back from the end of the month")},
+		{"@reboot /bin/job", nil, "",
+			fmt.Errorf("@reboot tasks don't run on a schedule, systemd triggers them with a unit dependency instead")},
+	}
+
+	for _, test := range tests {
+		task, err := CronTaskCompile(test.inputCronStr, test.inputOpts...)
+		if err != nil {
+			t.Fatalf("test %v, expected no error compiling, got %v", test.inputCronStr, err)
+		}
+		res, err := task.ToOnCalendar()
+		if fmt.Sprint(err) != fmt.Sprint(test.expectedError) {
+			t.Errorf("test %v, expected error \"%v\", got \"%v\"", test.inputCronStr, test.expectedError, err)
+		}
+		if res != test.expected {
+			t.Errorf("test %v, expected %v, got %v", test.inputCronStr, test.expected, res)
+		}
+
+		// The calendar event describes the same schedule
+		if err == nil {
+			roundTrip, err := ParseOnCalendar(res)
+			if err != nil {
+				t.Errorf("test %v, expected %v to parse, got %v", test.inputCronStr, res, err)
+				continue
+			}
+			if again, _ := roundTrip.ToOnCalendar(); again != res {
+				t.Errorf("test %v, expected %v after a round trip, got %v", test.inputCronStr, res, again)
+			}
+		}
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"strings"
 	"unicode"
 )
 
@@ -12,9 +13,21 @@ const (
 	TokenComma
 	TokenDash
 	TokenSlash
+	TokenLast
+	TokenWeekday
+	TokenHash
+	TokenHashed
+	TokenOpenParen
+	TokenCloseParen
+	TokenQuestion
 
+	TokenTimezone
+	TokenMacro
 	TokenNumber
+	TokenName
 	TokenSpace
+	TokenDuration
+	TokenUser
 	TokenCommand
 
 	TokenEOF
@@ -30,10 +43,34 @@ func (t TokenType) String() string {
 		return "Dash"
 	case TokenSlash:
 		return "Slash"
+	case TokenLast:
+		return "Last"
+	case TokenWeekday:
+		return "Weekday"
+	case TokenHash:
+		return "Hash"
+	case TokenHashed:
+		return "Hashed"
+	case TokenOpenParen:
+		return "OpenParen"
+	case TokenCloseParen:
+		return "CloseParen"
+	case TokenQuestion:
+		return "Question"
+	case TokenTimezone:
+		return "Timezone"
+	case TokenMacro:
+		return "Macro"
 	case TokenNumber:
 		return "Number"
+	case TokenName:
+		return "Name"
 	case TokenSpace:
 		return "Space"
+	case TokenDuration:
+		return "Duration"
+	case TokenUser:
+		return "User"
 	case TokenCommand:
 		return "Command"
 	case TokenEOF:
@@ -80,26 +117,228 @@ func TokenizeNumber(cronStrRunes *[]rune, start int) (tk Token, end int, success
 	return
 }
 
-func Tokenize(cronStr string) ([]Token, error) {
+func TokenizeName(cronStrRunes *[]rune, start int) (tk Token, end int, success bool) {
+
+	end = start
+
+	// Check for out of bounds
+	if len(*cronStrRunes) <= start {
+		success = false
+		return
+	}
+
+	// Keep fetching all letters until we hit a non-letter
+	for ; end < len(*cronStrRunes); end++ {
+		if !unicode.IsLetter((*cronStrRunes)[end]) {
+			break
+		}
+	}
+
+	// Check if we fetched any letters
+	if end == start {
+		success = false
+		return
+	}
+
+	tk = Token{TokenName, (*cronStrRunes)[start:end]}
+	success = true
+	return
+}
+
+// isBlank checks for the characters separating the fields of a cron string
+func isBlank(char rune) bool {
+	return char == ' ' || char == '\t'
+}
+
+// TokenizeBlanks groups a run of blanks into a single space token
+func TokenizeBlanks(cronStrRunes *[]rune, start int) (tk Token, end int, success bool) {
+
+	end = start
+
+	// Check for out of bounds
+	if len(*cronStrRunes) <= start {
+		success = false
+		return
+	}
+
+	// Keep fetching all blanks until we hit a non-blank
+	for ; end < len(*cronStrRunes); end++ {
+		if !isBlank((*cronStrRunes)[end]) {
+			break
+		}
+	}
+
+	// Check if we fetched any blanks
+	if end == start {
+		success = false
+		return
+	}
+
+	tk = Token{TokenSpace, (*cronStrRunes)[start:end]}
+	success = true
+	return
+}
+
+// timezonePrefixes lists the assignments which set a time zone for the line
+var timezonePrefixes = []string{"CRON_TZ=", "TZ="}
+
+// TokenizeTimezone reads the time zone assignment at the start of a cron
+// string, e.g. CRON_TZ=Europe/London, the token only holds the zone name
+func TokenizeTimezone(cronStrRunes *[]rune, start int) (tk Token, end int, success bool) {
+
+	end = start
+
+	// Look for any of the assignment prefixes
+	var prefix string
+	for _, timezonePrefix := range timezonePrefixes {
+		if strings.HasPrefix(string((*cronStrRunes)[start:]), timezonePrefix) {
+			prefix = timezonePrefix
+			break
+		}
+	}
+	if prefix == "" {
+		success = false
+		return
+	}
+	end += len([]rune(prefix))
+	nameStart := end
+
+	// The zone name runs until the next blank
+	for ; end < len(*cronStrRunes); end++ {
+		if isBlank((*cronStrRunes)[end]) {
+			break
+		}
+	}
+
+	tk = Token{TokenTimezone, (*cronStrRunes)[nameStart:end]}
+	success = true
+	return
+}
+
+// TokenizeUser reads the user column of a system crontab, which runs until
+// the next blank
+func TokenizeUser(cronStrRunes *[]rune, start int) (tk Token, end int, success bool) {
+	return tokenizeUntilBlank(cronStrRunes, start, TokenUser)
+}
+
+// TokenizeDuration reads the interval of an @every schedule, e.g. 1h30m,
+// which runs until the next blank
+func TokenizeDuration(cronStrRunes *[]rune, start int) (tk Token, end int, success bool) {
+	return tokenizeUntilBlank(cronStrRunes, start, TokenDuration)
+}
+
+func tokenizeUntilBlank(cronStrRunes *[]rune, start int, tokType TokenType) (tk Token, end int, success bool) {
+
+	end = start
+
+	// Check for out of bounds
+	if len(*cronStrRunes) <= start {
+		success = false
+		return
+	}
+
+	// Keep fetching all characters until we hit a blank
+	for ; end < len(*cronStrRunes); end++ {
+		if isBlank((*cronStrRunes)[end]) {
+			break
+		}
+	}
+
+	tk = Token{tokType, (*cronStrRunes)[start:end]}
+	success = true
+	return
+}
+
+// classifyName splits out the L and W day operators and the H hash operator
+// from name tokens
+func classifyName(tk Token) []Token {
+	switch strings.ToUpper(string(tk.value)) {
+	case "H":
+		return []Token{{TokenHashed, tk.value}}
+	case "L":
+		return []Token{{TokenLast, tk.value}}
+	case "W":
+		return []Token{{TokenWeekday, tk.value}}
+	case "LW":
+		return []Token{{TokenLast, tk.value[:1]}, {TokenWeekday, tk.value[1:]}}
+	default:
+		return []Token{tk}
+	}
+}
+
+func Tokenize(cronStr string, opts ...CompileOption) ([]Token, error) {
 
 	tokens := make([]Token, 0)
 	invalidTokens := make([]string, 0)
 	space_count := 0
+	options := getCompileOptions(opts)
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+	// number of space-separated fields preceding the command
+	field_count := len(options.timeFields())
+	gotUser := false
 
 	invalid := false
 
-	runes := []rune(cronStr)
+	// Whitespace around the cron string isn't part of any field
+	runes := []rune(strings.TrimSpace(cronStr))
 
-	for i := 0; i < len(runes); i++ {
+	// A time zone assignment may precede the schedule, it isn't a time field
+	start := 0
+	timezoneToken, end, success := TokenizeTimezone(&runes, 0)
+	if success {
+		prefix := string(runes[:end-len(timezoneToken.value)])
+		if err := options.Dialect.checkTimezonePrefix(prefix); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, timezoneToken)
+		spaceToken, blanksEnd, gotBlanks := TokenizeBlanks(&runes, end)
+		if gotBlanks {
+			tokens = append(tokens, spaceToken)
+		}
+		start = blanksEnd
+	}
+	scheduleStart := len(tokens)
+
+	for i := start; i < len(runes); i++ {
 		char := runes[i]
 
-		// If we've seen 5 spaces, we're done and the rest is the command
-		if space_count >= 5 && i < len(runes) {
+		// If we've seen all the fields, we're done and the rest is the command,
+		// kept exactly as written
+		if space_count >= field_count && i < len(runes) {
+			// A system crontab names the user to run the command as first
+			if options.SystemCrontab && !gotUser {
+				userToken, end, _ := TokenizeUser(&runes, i)
+				tokens = append(tokens, userToken)
+				spaceToken, blanksEnd, gotBlanks := TokenizeBlanks(&runes, end)
+				if gotBlanks {
+					tokens = append(tokens, spaceToken)
+				}
+				gotUser = true
+				i = blanksEnd - 1
+				continue
+			}
 			tokens = append(tokens, Token{TokenCommand, runes[i:]})
 			break
 		}
 
 		switch {
+		case char == '@' && len(tokens) == scheduleStart:
+			// A predefined schedule replaces all of the time fields
+			_, end, _ := TokenizeName(&runes, i+1)
+			tokens = append(tokens, Token{TokenMacro, runes[i:end]})
+			field_count = 1
+			// The interval of an @every schedule follows it
+			if string(runes[i:end]) == everyMacro {
+				spaceToken, blanksEnd, gotBlanks := TokenizeBlanks(&runes, end)
+				durationToken, durationEnd, gotDuration := TokenizeDuration(&runes, blanksEnd)
+				if gotBlanks && gotDuration {
+					tokens = append(tokens, spaceToken, durationToken)
+					end = durationEnd
+				}
+			}
+			i = end - 1
 		case char == '*':
 			tokens = append(tokens, Token{TokenAsterisk, runes[i : i+1]})
 		case char == ',':
@@ -108,31 +347,57 @@ func Tokenize(cronStr string) ([]Token, error) {
 			tokens = append(tokens, Token{TokenDash, runes[i : i+1]})
 		case char == '/':
 			tokens = append(tokens, Token{TokenSlash, runes[i : i+1]})
-		case char == ' ':
-			tokens = append(tokens, Token{TokenSpace, runes[i : i+1]})
+		case char == '#':
+			tokens = append(tokens, Token{TokenHash, runes[i : i+1]})
+		case char == '(':
+			tokens = append(tokens, Token{TokenOpenParen, runes[i : i+1]})
+		case char == ')':
+			tokens = append(tokens, Token{TokenCloseParen, runes[i : i+1]})
+		case char == '?':
+			tokens = append(tokens, Token{TokenQuestion, runes[i : i+1]})
+		case isBlank(char):
+			// Any run of spaces and tabs separates two fields
+			spaceToken, end, _ := TokenizeBlanks(&runes, i)
+			tokens = append(tokens, spaceToken)
 			space_count++
+			i = end - 1
 		case unicode.IsDigit(char):
 			numToken, end, success := TokenizeNumber(&runes, i)
 			if success {
 				tokens = append(tokens, numToken)
 			}
 			i = end - 1
+		case unicode.IsLetter(char):
+			nameToken, end, success := TokenizeName(&runes, i)
+			if success {
+				tokens = append(tokens, classifyName(nameToken)...)
+			}
+			i = end - 1
 		default:
 			invalidTokens = append(invalidTokens, string(char))
 			invalid = true
 		}
 	}
 
+	if invalid && options.ScheduleOnly {
+		return nil, fmt.Errorf("invalid Tokens found in the schedule: %v, need %v space-separated time fields",
+			invalidTokens, len(options.timeFields()))
+	}
 	if invalid {
 		return nil, fmt.Errorf(
-			"invalid Tokens found in the cron string: %v, need 5 time space-separated time fields followed by a command",
-			invalidTokens)
+			"invalid Tokens found in the cron string: %v, need %v time space-separated time fields followed by a command",
+			invalidTokens, len(options.timeFields()))
 	}
 
 	if len(tokens) == 0 {
 		return nil, fmt.Errorf("didn't find any valid characters in the cron string")
 	}
 
+	// Only keep the operators the dialect accepts
+	if err := options.Dialect.checkTokens(tokens); err != nil {
+		return nil, err
+	}
+
 	tokens = append(tokens, Token{TokenEOF, []rune("")})
 
 	return tokens, nil
@@ -50,6 +50,96 @@ func TestTokenizeNumber(t *testing.T) {
 	}
 }
 
+func TestTokenizeName(t *testing.T) {
+
+	tests := []struct {
+		input           []rune
+		expectedValue   string
+		expectedEnd     int
+		expectedSuccess bool
+	}{
+		{make([]rune, 0), "", 0, false},
+		{[]rune("MON"), "MON", 3, true},
+		{[]rune("jan-"), "jan", 3, true},
+		{[]rune("12"), "", 0, false},
+	}
+
+	for i, test := range tests {
+		cronStrRunes := []rune(test.input)
+		token, end, success := TokenizeName(&cronStrRunes, 0)
+
+		// Should return the correct success value
+		if success != test.expectedSuccess {
+			t.Errorf("test %v, expected success to be %v, got %v", i, test.expectedSuccess, success)
+		}
+
+		// No need to test the value
+		if success == false {
+			continue
+		}
+
+		// Should return a TokenName
+		if token.tokType != TokenName {
+			t.Errorf("test %v, expected TokenName, got %v", i, token.tokType)
+		}
+
+		// Should tokenize the value correctly
+		if string(token.value) != test.expectedValue {
+			t.Errorf("test %v, expected %v, got %v", i, test.expectedValue, string(token.value))
+		}
+
+		// Expect to parse the whole name available
+		if end != test.expectedEnd {
+			t.Errorf("test %v, expected end to be %v, got %v", i, test.expectedEnd, end)
+		}
+	}
+}
+
+func TestTokenizeBlanks(t *testing.T) {
+
+	tests := []struct {
+		input           []rune
+		expectedValue   string
+		expectedEnd     int
+		expectedSuccess bool
+	}{
+		{make([]rune, 0), "", 0, false},
+		{[]rune(" "), " ", 1, true},
+		{[]rune(" \t  1"), " \t  ", 4, true},
+		{[]rune("1 "), "", 0, false},
+	}
+
+	for i, test := range tests {
+		cronStrRunes := []rune(test.input)
+		token, end, success := TokenizeBlanks(&cronStrRunes, 0)
+
+		// Should return the correct success value
+		if success != test.expectedSuccess {
+			t.Errorf("test %v, expected success to be %v, got %v", i, test.expectedSuccess, success)
+		}
+
+		// No need to test the value
+		if success == false {
+			continue
+		}
+
+		// Should return a TokenSpace
+		if token.tokType != TokenSpace {
+			t.Errorf("test %v, expected TokenSpace, got %v", i, token.tokType)
+		}
+
+		// Should tokenize the value correctly
+		if string(token.value) != test.expectedValue {
+			t.Errorf("test %v, expected %q, got %q", i, test.expectedValue, string(token.value))
+		}
+
+		// Expect to group all of the blanks available
+		if end != test.expectedEnd {
+			t.Errorf("test %v, expected end to be %v, got %v", i, test.expectedEnd, end)
+		}
+	}
+}
+
 func TestTokenize(t *testing.T) {
 
 	var tests = []struct {
@@ -69,6 +159,76 @@ func TestTokenize(t *testing.T) {
 			{TokenNumber, []rune("54")},
 			{TokenEOF, []rune("")},
 		}, nil},
+		{"MON-fri", []Token{
+			{TokenName, []rune("MON")},
+			{TokenDash, []rune("-")},
+			{TokenName, []rune("fri")},
+			{TokenEOF, []rune("")},
+		}, nil},
+		{"@every 1h30m cmd *", []Token{
+			{TokenMacro, []rune("@every")},
+			{TokenSpace, []rune(" ")},
+			{TokenDuration, []rune("1h30m")},
+			{TokenSpace, []rune(" ")},
+			{TokenCommand, []rune("cmd *")},
+			{TokenEOF, []rune("")},
+		}, nil},
+		{"@daily cmd *", []Token{
+			{TokenMacro, []rune("@daily")},
+			{TokenSpace, []rune(" ")},
+			{TokenCommand, []rune("cmd *")},
+			{TokenEOF, []rune("")},
+		}, nil},
+		{"L-3,15W,LW,JUL", []Token{
+			{TokenLast, []rune("L")},
+			{TokenDash, []rune("-")},
+			{TokenNumber, []rune("3")},
+			{TokenComma, []rune(",")},
+			{TokenNumber, []rune("15")},
+			{TokenWeekday, []rune("W")},
+			{TokenComma, []rune(",")},
+			{TokenLast, []rune("L")},
+			{TokenWeekday, []rune("W")},
+			{TokenComma, []rune(",")},
+			{TokenName, []rune("JUL")},
+			{TokenEOF, []rune("")},
+		}, nil},
+		{"1#2,5L", []Token{
+			{TokenNumber, []rune("1")},
+			{TokenHash, []rune("#")},
+			{TokenNumber, []rune("2")},
+			{TokenComma, []rune(",")},
+			{TokenNumber, []rune("5")},
+			{TokenLast, []rune("L")},
+			{TokenEOF, []rune("")},
+		}, nil},
+		{"H(0-29)/10", []Token{
+			{TokenHashed, []rune("H")},
+			{TokenOpenParen, []rune("(")},
+			{TokenNumber, []rune("0")},
+			{TokenDash, []rune("-")},
+			{TokenNumber, []rune("29")},
+			{TokenCloseParen, []rune(")")},
+			{TokenSlash, []rune("/")},
+			{TokenNumber, []rune("10")},
+			{TokenEOF, []rune("")},
+		}, nil},
+		{" 1 \t*\tcmd  \t", []Token{
+			{TokenNumber, []rune("1")},
+			{TokenSpace, []rune(" \t")},
+			{TokenAsterisk, []rune("*")},
+			{TokenSpace, []rune("\t")},
+			{TokenName, []rune("cmd")},
+			{TokenEOF, []rune("")},
+		}, nil},
+		{"CRON_TZ=Europe/London @daily cmd", []Token{
+			{TokenTimezone, []rune("Europe/London")},
+			{TokenSpace, []rune(" ")},
+			{TokenMacro, []rune("@daily")},
+			{TokenSpace, []rune(" ")},
+			{TokenCommand, []rune("cmd")},
+			{TokenEOF, []rune("")},
+		}, nil},
 		{"&", []Token{{TokenEOF, []rune("")}}, fmt.Errorf("invalid Tokens found in the cron string: [&], need 5 time space-separated time fields followed by a command")},
 	}
 
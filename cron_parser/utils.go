@@ -1,6 +1,8 @@
 package main
 
 import (
+	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -13,3 +15,45 @@ func IntSliceToString(slice []int) string {
 	}
 	return strings.TrimRight(sb.String(), " ")
 }
+
+// YearsToString lists year values, space-separated, with runs of three or more
+// consecutive years written as a range so that * doesn't list every year
+func YearsToString(years []int) string {
+	var sb strings.Builder
+	for i := 0; i < len(years); {
+		j := i
+		for j+1 < len(years) && years[j+1] == years[j]+1 {
+			j++
+		}
+		if sb.Len() > 0 {
+			sb.WriteString(" ")
+		}
+		if j-i >= 2 {
+			sb.WriteString(fmt.Sprintf("%v-%v", years[i], years[j]))
+			i = j + 1
+			continue
+		}
+		sb.WriteString(strconv.Itoa(years[i]))
+		i++
+	}
+	return sb.String()
+}
+
+// DaysToString lists day values followed by the day rules, space-separated
+func DaysToString(days []int, rules []DayRule) string {
+	var sb strings.Builder
+	sb.WriteString(IntSliceToString(days))
+	for _, rule := range rules {
+		if sb.Len() > 0 {
+			sb.WriteString(" ")
+		}
+		sb.WriteString(rule.String())
+	}
+	return sb.String()
+}
+
+// IntSliceContains checks whether a sorted slice contains a value
+func IntSliceContains(slice []int, val int) bool {
+	i := sort.SearchInts(slice, val)
+	return i < len(slice) && slice[i] == val
+}
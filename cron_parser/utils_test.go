@@ -20,3 +20,64 @@ func TestIntSliceToString(t *testing.T) {
 		}
 	}
 }
+
+func TestYearsToString(t *testing.T) {
+	tests := []struct {
+		inputSlice []int
+		expected   string
+	}{
+		{[]int{2027}, "2027"},
+		{[]int{2027, 2028}, "2027 2028"},
+		{[]int{2027, 2028, 2029}, "2027-2029"},
+		{[]int{2020, 2025, 2026, 2027, 2030, 2031}, "2020 2025-2027 2030 2031"},
+		{[]int{}, ""},
+	}
+
+	for i, test := range tests {
+		res := YearsToString(test.inputSlice)
+
+		if res != test.expected {
+			t.Errorf("test %v, expected %v, got %v", i, test.expected, res)
+		}
+	}
+}
+
+func TestDaysToString(t *testing.T) {
+	tests := []struct {
+		inputDays  []int
+		inputRules []DayRule
+		expected   string
+	}{
+		{[]int{1, 15}, nil, "1 15"},
+		{[]int{}, []DayRule{{Kind: DayRuleLastDayOfMonth, Offset: 2}}, "L-2"},
+		{[]int{1}, []DayRule{{Kind: DayRuleNearestWeekday, Day: 15}, {Kind: DayRuleLastWeekday}}, "1 15W LW"},
+	}
+
+	for i, test := range tests {
+		res := DaysToString(test.inputDays, test.inputRules)
+
+		if res != test.expected {
+			t.Errorf("test %v, expected %v, got %v", i, test.expected, res)
+		}
+	}
+}
+
+func TestIntSliceContains(t *testing.T) {
+	tests := []struct {
+		inputSlice []int
+		inputVal   int
+		expected   bool
+	}{
+		{[]int{1, 2, 3}, 2, true},
+		{[]int{1, 2, 3}, 4, false},
+		{[]int{}, 0, false},
+	}
+
+	for i, test := range tests {
+		res := IntSliceContains(test.inputSlice, test.inputVal)
+
+		if res != test.expected {
+			t.Errorf("test %v, expected %v, got %v", i, test.expected, res)
+		}
+	}
+}